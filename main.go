@@ -1,22 +1,40 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"strings"
+	"time"
+	// exchange time zones are needed to label chart axes, and the docker image has no tzdata
+	_ "time/tzdata"
 
 	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"quote-telegram-bot/pkg/analytics"
 	"quote-telegram-bot/pkg/helpers"
 	"quote-telegram-bot/pkg/yfapi"
 )
 
+// UserSettings are preferences chosen by a user with commands.
+type UserSettings struct {
+	Output yfapi.ChartOutput
+	// QuoteCards sends quotes as rendered cards instead of text messages
+	QuoteCards bool
+}
+
 var (
 	botToken string
 	debug    bool
 	version  bool
+	// heatmapLists is a JSON file with symbol lists available to /heatmap by name
+	heatmapLists string
+	// benchmark is a symbol beta in /stats is calculated against
+	benchmark string
+	// riskFreeRate is an annual rate in percent Sharpe and Sortino ratios are calculated over
+	riskFreeRate float64
 
 	Name    string
 	Version string
@@ -28,6 +46,14 @@ func init() {
 	flag.StringVar(&botToken, "botToken", os.Getenv("BOT_TOKEN"), "Telegram bot token")
 	flag.BoolVar(&debug, "debug", debugEnv, "Enable debug")
 	flag.BoolVar(&version, "version", false, "Print version")
+	flag.StringVar(&heatmapLists, "heatmapLists", os.Getenv("HEATMAP_LISTS"), "JSON file with symbol lists for /heatmap")
+	benchmarkEnv := os.Getenv("BENCHMARK")
+	if benchmarkEnv == "" {
+		benchmarkEnv = "^GSPC"
+	}
+	flag.StringVar(&benchmark, "benchmark", benchmarkEnv, "Benchmark symbol of /stats")
+	riskFreeRateEnv, _ := strconv.ParseFloat(os.Getenv("RISK_FREE_RATE"), 64)
+	flag.Float64Var(&riskFreeRate, "riskFreeRate", riskFreeRateEnv, "Annual risk-free rate in percent for /stats")
 	flag.Parse()
 }
 
@@ -37,6 +63,12 @@ func main() {
 		return
 	}
 
+	if heatmapLists != "" {
+		if err := yfapi.LoadHeatmapLists(heatmapLists); err != nil {
+			panic(err)
+		}
+	}
+
 	bot, err := tgbot.NewBotAPI(botToken)
 	if err != nil {
 		panic(err)
@@ -49,6 +81,10 @@ func main() {
 
 	yfc := yfapi.NewYFClient()
 
+	// settings chosen by users, they are kept in memory and reset on restart
+	settings := make(map[int]UserSettings)
+	charts := yfapi.NewChartCache()
+
 	for update := range updates {
 		// skip edited messages events
 		if update.EditedMessage != nil {
@@ -59,9 +95,37 @@ func main() {
 
 		// update.CallbackQuery used to process button presses
 		if update.CallbackQuery != nil {
+			callback, err := yfapi.DecodeCallback(update.CallbackQuery.Data)
+			if err != nil {
+				log.Println(err)
+				if serr, ok := err.(*yfapi.StaleCallbackError); ok {
+					if _, err := bot.Request(tgbot.NewCallbackWithAlert(update.CallbackQuery.ID, serr.Error())); err != nil {
+						log.Println(err)
+					}
+				}
+				continue
+			}
+
 			// process search result button press
-			if len(strings.Split(update.CallbackQuery.Data, "|")) == 1 {
-				QueryQuote(yfc, update.CallbackQuery.Data, msg)
+			if callback.Action == yfapi.ActionQuote {
+				user := settings[update.CallbackQuery.From.ID]
+				if user.QuoteCards {
+					if photo := QuoteCard(yfc, callback.Symbol, user.Output, msg); photo != nil {
+						err := helpers.Retry(3, func() error {
+							if _, err := bot.Send(photo); err != nil {
+								return err
+							}
+							return nil
+						})
+						if err != nil {
+							log.Println(err)
+						}
+						continue
+					}
+				}
+				if msg.Text == "" {
+					QueryQuote(yfc, callback.Symbol, msg)
+				}
 				err := helpers.Retry(3, func() error {
 					if _, err := bot.Send(msg); err != nil {
 						return err
@@ -74,44 +138,63 @@ func main() {
 				continue
 			}
 
-			// generate chart button press metadata
-			params, err := yfapi.NewChartParams(update.CallbackQuery.Data)
-			if err != nil {
-				log.Println(err)
-				continue
-			}
+			// chart button press metadata
+			params := callback.Chart
+			params.Output = settings[update.CallbackQuery.From.ID].Output
 
-			// price and earnings/revenue charts have different sources and formats, but same interface
-			var data yfapi.Chartable
-			switch params.Measurement {
-			case "price":
-				data, err = yfc.GetPriceChart(params.Symbol, params.Interval)
-			case "earnings", "revenue":
-				data, err = yfc.GetQuote(params.Symbol)
-			default:
+			if params.Action == yfapi.ActionChartAlbum {
+				if err := SendChartAlbum(bot, yfc, charts, params, update.CallbackQuery.Message.Chat.ID); err != nil {
+					log.Println(err)
+				}
 				continue
 			}
 
-			if err != nil {
-				log.Println(err)
-				continue
-			}
+			// identical charts are not rendered again until their data gets stale
+			cached, ok := charts.Get(params)
+			if !ok {
+				// price and earnings/revenue charts have different sources and formats, but same interface
+				var data yfapi.Chartable
+				switch params.Measurement {
+				case "price":
+					data, err = yfc.GetPriceChartWithLookback(params.Symbol, params.Interval, params.Lookback())
+				case "earnings", "revenue", "holdings", "sectors":
+					data, err = yfc.GetQuote(params.Symbol)
+				case "compare":
+					data, err = yfc.GetComparison(strings.Split(params.Symbol, ","), params.Interval)
+				case "drawdown":
+					data, err = analytics.GetStats(yfc, params.Symbol, params.Interval, "", riskFreeRate)
+				default:
+					continue
+				}
+
+				if err != nil {
+					log.Println(err)
+					continue
+				}
 
-			switch params.Cmd {
-			// initial received only once when user press button "Charts" under quote info message.
-			// Price chart sent on this event.
-			case "initial":
 				chart, err := data.ChartBytes(params)
 				if err != nil {
 					log.Println(err)
 					continue
 				}
-				graph := tgbot.NewPhotoUpload(update.CallbackQuery.Message.Chat.ID, chart)
-				graph.ReplyMarkup = yfapi.ChartKeyboard(params, data.Intervals())
+				cached = charts.Put(params, chart, data.Intervals())
+			}
+
+			switch params.Action {
+			// ActionChart received only once when user press button "Charts" under quote info message.
+			// Price chart sent on this event.
+			case yfapi.ActionChart:
+				graph := tgbot.NewPhotoUpload(update.CallbackQuery.Message.Chat.ID, cached.File)
+				if cached.FileID != "" {
+					graph = tgbot.NewPhotoShare(update.CallbackQuery.Message.Chat.ID, cached.FileID)
+				}
+				graph.ReplyMarkup = yfapi.ChartKeyboard(params, cached.Intervals)
 				err = helpers.Retry(3, func() error {
-					if _, err := bot.Send(graph); err != nil {
+					sent, err := bot.Send(graph)
+					if err != nil {
 						return err
 					}
+					charts.SetFileID(params, sent)
 					return nil
 				})
 				if err != nil {
@@ -119,16 +202,22 @@ func main() {
 				}
 			default:
 				// any chart updates are processing here
-				chart, err := data.ChartBytes(params)
-				if err != nil {
-					log.Println(err)
-					continue
-				}
-				p := yfapi.NewMediaUpdateParams(update.CallbackQuery.Message, params, data.Intervals())
+				p := yfapi.NewMediaUpdateParams(update.CallbackQuery.Message, params, cached.Intervals, cached.FileID)
 				err = helpers.Retry(3, func() error {
-					if _, err = bot.UploadFile("editMessageMedia", p, "charts.png", chart); err != nil {
+					var resp tgbot.APIResponse
+					if cached.FileID != "" {
+						resp, err = bot.MakeRequest("editMessageMedia", p)
+					} else {
+						resp, err = bot.UploadFile("editMessageMedia", p, "charts.png", cached.File)
+					}
+					if err != nil {
 						return err
 					}
+
+					var edited tgbot.Message
+					if err := json.Unmarshal(resp.Result, &edited); err == nil {
+						charts.SetFileID(params, edited)
+					}
 					return nil
 				})
 				if err != nil {
@@ -143,8 +232,138 @@ func main() {
 		switch s := update.Message.Command(); s {
 		case "start", "help":
 			msg.Text = yfapi.HelpMessage(update.Message.From.LanguageCode)
+		case "convert":
+			ConvertCurrency(yfc, update.Message.CommandArguments(), msg)
+		case "compare":
+			if photo := CompareSymbols(yfc, update.Message.CommandArguments(), settings[update.Message.From.ID].Output, msg); photo != nil {
+				err := helpers.Retry(3, func() error {
+					if _, err := bot.Send(photo); err != nil {
+						return err
+					}
+					return nil
+				})
+				if err != nil {
+					log.Println(err)
+				}
+				continue
+			}
+		case "chart":
+			if photo := PriceChart(yfc, update.Message.CommandArguments(), settings[update.Message.From.ID].Output, msg); photo != nil {
+				err := helpers.Retry(3, func() error {
+					if _, err := bot.Send(photo); err != nil {
+						return err
+					}
+					return nil
+				})
+				if err != nil {
+					log.Println(err)
+				}
+				continue
+			}
+		case "export":
+			if document := ExportChart(yfc, update.Message.CommandArguments(), settings[update.Message.From.ID].Output, msg); document != nil {
+				err := helpers.Retry(3, func() error {
+					if _, err := bot.Send(document); err != nil {
+						return err
+					}
+					return nil
+				})
+				if err != nil {
+					log.Println(err)
+				}
+				continue
+			}
+		case "theme":
+			user := settings[update.Message.From.ID]
+			SetChartTheme(update.Message.CommandArguments(), &user.Output, msg)
+			settings[update.Message.From.ID] = user
+		case "size":
+			user := settings[update.Message.From.ID]
+			SetChartScale(update.Message.CommandArguments(), &user.Output, msg)
+			settings[update.Message.From.ID] = user
+		case "format":
+			user := settings[update.Message.From.ID]
+			SetQuoteFormat(update.Message.CommandArguments(), &user, msg)
+			settings[update.Message.From.ID] = user
+		case "ta":
+			TechnicalSummary(yfc, update.Message.CommandArguments(), msg)
+		case "stats":
+			RiskStats(yfc, update.Message.CommandArguments(), msg)
+		case "correlation":
+			if photo := CorrelationMatrix(yfc, update.Message.CommandArguments(), settings[update.Message.From.ID].Output, msg); photo != nil {
+				err := helpers.Retry(3, func() error {
+					if _, err := bot.Send(photo); err != nil {
+						return err
+					}
+					return nil
+				})
+				if err != nil {
+					log.Println(err)
+				}
+				continue
+			}
+		case "backtest":
+			if photo := RunBacktest(yfc, update.Message.CommandArguments(), settings[update.Message.From.ID].Output, msg); photo != nil {
+				err := helpers.Retry(3, func() error {
+					if _, err := bot.Send(photo); err != nil {
+						return err
+					}
+					return nil
+				})
+				if err != nil {
+					log.Println(err)
+				}
+				continue
+			}
+		case "heatmap":
+			if photo := MarketHeatmap(yfc, update.Message.CommandArguments(), settings[update.Message.From.ID].Output, msg); photo != nil {
+				err := helpers.Retry(3, func() error {
+					if _, err := bot.Send(photo); err != nil {
+						return err
+					}
+					return nil
+				})
+				if err != nil {
+					log.Println(err)
+				}
+				continue
+			}
+		case "fx":
+			if photo := CrossRates(yfc, update.Message.CommandArguments(), settings[update.Message.From.ID].Output, msg); photo != nil {
+				err := helpers.Retry(3, func() error {
+					if _, err := bot.Send(photo); err != nil {
+						return err
+					}
+					return nil
+				})
+				if err != nil {
+					log.Println(err)
+				}
+				continue
+			}
 		// any text messages are processing here
 		case "":
+			if _, _, _, ok := yfapi.ParseConversion(update.Message.Text); ok {
+				ConvertCurrency(yfc, update.Message.Text, msg)
+				break
+			}
+			if user := settings[update.Message.From.ID]; user.QuoteCards {
+				if photo := QuoteCard(yfc, update.Message.Text, user.Output, msg); photo != nil {
+					err := helpers.Retry(3, func() error {
+						if _, err := bot.Send(photo); err != nil {
+							return err
+						}
+						return nil
+					})
+					if err != nil {
+						log.Println(err)
+					}
+					continue
+				}
+				if msg.Text != "" {
+					break
+				}
+			}
 			QueryQuote(yfc, update.Message.Text, msg)
 		default:
 			if update.Message.Text != "" && s != update.Message.Text {
@@ -204,3 +423,500 @@ func QueryQuote(yfc *yfapi.YFClient, symbol string, msg *tgbot.MessageConfig) {
 		msg.Text = fmt.Sprintf("No data found for symbol: %s", symbol)
 	}
 }
+
+func ConvertCurrency(yfc *yfapi.YFClient, text string, msg *tgbot.MessageConfig) {
+	amount, from, to, ok := yfapi.ParseConversion(text)
+	if !ok {
+		msg.Text = "Usage: /convert AMOUNT FROM TO, e.g. /convert 100 USD EUR"
+		return
+	}
+
+	conversion, err := yfc.Convert(amount, from, to)
+	if err != nil {
+		msg.Text = fmt.Sprintf("Unable to convert %s to %s", from, to)
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error()
+		}
+		return
+	}
+
+	msg.Text = conversion.ConversionMessage()
+}
+
+// CrossRates fills msg with a cross-rate table, or returns a photo with rendered table when it is too wide for text.
+func CrossRates(yfc *yfapi.YFClient, text string, output yfapi.ChartOutput, msg *tgbot.MessageConfig) *tgbot.PhotoConfig {
+	rates, err := yfc.CrossRates(yfapi.ParseCurrencies(text))
+	if err != nil {
+		msg.Text = "Unable to get cross rates"
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error() + "\nUsage: /fx USD EUR GBP JPY"
+		}
+		return nil
+	}
+
+	if rates.FitsText() {
+		msg.Text = rates.CrossRatesMessage()
+		return nil
+	}
+
+	table, err := rates.TableBytes(output)
+	if err != nil {
+		msg.Text = rates.CrossRatesMessage()
+		log.Println(err)
+		return nil
+	}
+
+	photo := tgbot.NewPhotoUpload(msg.ChatID, table)
+	photo.Caption = rates.CrossRatesCaption()
+	photo.ParseMode = tgbot.ModeMarkdown
+
+	return &photo
+}
+
+// CompareSymbols returns a photo with price changes of several symbols plotted together, or fills msg with an error.
+func CompareSymbols(yfc *yfapi.YFClient, text string, output yfapi.ChartOutput, msg *tgbot.MessageConfig) *tgbot.PhotoConfig {
+	symbols := strings.Fields(strings.ToUpper(text))
+	params := &yfapi.ChartParams{
+		Symbol:      strings.Join(symbols, ","),
+		Interval:    "1mo",
+		Measurement: "compare",
+		Action:      yfapi.ActionChart,
+		Type:        "-",
+		Output:      output,
+	}
+
+	comparison, err := yfc.GetComparison(symbols, params.Interval)
+	if err != nil {
+		msg.Text = "Unable to compare: " + text
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error() + "\nUsage: /compare AAPL MSFT QQQ"
+		}
+		if eerr, ok := err.(*yfapi.EmptyDataError); ok {
+			msg.Text = eerr.Error()
+		}
+		return nil
+	}
+
+	chart, err := comparison.ChartBytes(params)
+	if err != nil {
+		msg.Text = "Unable to compare: " + text
+		log.Println(err)
+		return nil
+	}
+
+	photo := tgbot.NewPhotoUpload(msg.ChatID, chart)
+	photo.ReplyMarkup = yfapi.ChartKeyboard(params, comparison.Intervals())
+
+	return &photo
+}
+
+// TechnicalSummary fills msg with a textual summary of technical indicators of a symbol.
+func TechnicalSummary(yfc *yfapi.YFClient, text string, msg *tgbot.MessageConfig) {
+	args := strings.Fields(text)
+	if len(args) != 1 {
+		msg.Text = "Usage: /ta SYMBOL, e.g. /ta AAPL"
+		return
+	}
+
+	symbol := strings.ToUpper(args[0])
+	summary, err := yfc.GetTechnicalSummary(symbol)
+	if err != nil {
+		msg.Text = fmt.Sprintf("Unable to get technical summary for symbol: %s", symbol)
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error()
+		}
+		if eerr, ok := err.(*yfapi.EmptyDataError); ok {
+			msg.Text = eerr.Error()
+		}
+		if ierr, ok := err.(*yfapi.InsufficientDataError); ok {
+			msg.Text = ierr.Error()
+		}
+		return
+	}
+
+	msg.Text = summary.TechnicalMessage()
+	msg.ReplyMarkup = summary.TechnicalMessageInlineKeyboard()
+}
+
+// RiskStats fills msg with risk and performance statistics of a symbol and a button plotting its drawdown.
+func RiskStats(yfc *yfapi.YFClient, text string, msg *tgbot.MessageConfig) {
+	usage := "Usage: /stats SYMBOL [RANGE] [BENCHMARK], e.g. /stats AAPL 5y, where RANGE is one of " + strings.Join(yfapi.StatsRanges, ", ")
+	args := strings.Fields(text)
+	if len(args) < 1 || len(args) > 3 {
+		msg.Text = usage
+		return
+	}
+
+	symbol, period, bench := strings.ToUpper(args[0]), "1y", benchmark
+	if len(args) > 1 {
+		period = strings.ToLower(args[1])
+	}
+	if len(args) > 2 {
+		bench = strings.ToUpper(args[2])
+	}
+	if !yfapi.HasStatsRange(period) {
+		msg.Text = usage
+		return
+	}
+
+	stats, err := analytics.GetStats(yfc, symbol, period, bench, riskFreeRate)
+	if err != nil {
+		msg.Text = fmt.Sprintf("Unable to get statistics for symbol: %s", symbol)
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error()
+		}
+		if eerr, ok := err.(*yfapi.EmptyDataError); ok {
+			msg.Text = eerr.Error()
+		}
+		if ierr, ok := err.(*yfapi.InsufficientDataError); ok {
+			msg.Text = ierr.Error()
+		}
+		return
+	}
+
+	msg.Text = stats.StatsMessage()
+	msg.ReplyMarkup = stats.StatsMessageInlineKeyboard()
+}
+
+// CorrelationMatrix returns a photo with correlations of daily returns of symbols, or fills msg with an error.
+// Range is optional and goes last, e.g. AAPL MSFT GLD 5y.
+func CorrelationMatrix(yfc *yfapi.YFClient, text string, output yfapi.ChartOutput, msg *tgbot.MessageConfig) *tgbot.PhotoConfig {
+	symbols, period := strings.Fields(strings.ToUpper(text)), "1y"
+	if n := len(symbols); n > 0 && yfapi.HasStatsRange(strings.ToLower(symbols[n-1])) {
+		symbols, period = symbols[:n-1], strings.ToLower(symbols[n-1])
+	}
+
+	correlation, err := analytics.GetCorrelation(yfc, symbols, period)
+	if err != nil {
+		msg.Text = "Unable to correlate: " + text
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error() + "\nUsage: /correlation AAPL MSFT GLD [RANGE], where RANGE is one of " + strings.Join(yfapi.StatsRanges, ", ")
+		}
+		if eerr, ok := err.(*yfapi.EmptyDataError); ok {
+			msg.Text = eerr.Error()
+		}
+		if ierr, ok := err.(*yfapi.InsufficientDataError); ok {
+			msg.Text = ierr.Error()
+		}
+		return nil
+	}
+
+	matrix, err := correlation.MatrixBytes(output)
+	if err != nil {
+		msg.Text = "Unable to draw correlation matrix: " + text
+		log.Println(err)
+		return nil
+	}
+
+	photo := tgbot.NewPhotoUpload(msg.ChatID, matrix)
+	photo.Caption = correlation.CorrelationCaption()
+
+	return &photo
+}
+
+// RunBacktest returns a photo with equity of a strategy against buy and hold along with its results,
+// or fills msg with an error. Start of the backtest is either a range, a year or a date, 10y by default.
+func RunBacktest(yfc *yfapi.YFClient, text string, output yfapi.ChartOutput, msg *tgbot.MessageConfig) *tgbot.PhotoConfig {
+	usage := "Usage: /backtest SYMBOL STRATEGY [PARAMS] [SINCE], e.g. /backtest SPY sma 50 200 2010, where STRATEGY is\n" +
+		"hold - buy and hold\n" +
+		"sma [FAST] [SLOW] - SMA crossover, 50 and 200 by default\n" +
+		"rsi [PERIOD] [LOW] [HIGH] - buy below LOW, sell above HIGH, 14, 30 and 70 by default\n" +
+		"SINCE is a year, a date like 2010-01-01 or one of " + strings.Join(yfapi.StatsRanges, ", ")
+	args := strings.Fields(text)
+	if len(args) < 2 {
+		msg.Text = usage
+		return nil
+	}
+
+	symbol := strings.ToUpper(args[0])
+	strategy, period, err := analytics.ParseBacktestArgs(args[1:], time.Now().UTC())
+	if err != nil {
+		msg.Text = err.Error() + "\n" + usage
+		return nil
+	}
+
+	backtest, err := analytics.GetBacktest(yfc, symbol, period, strategy)
+	if err != nil {
+		msg.Text = fmt.Sprintf("Unable to backtest symbol: %s", symbol)
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error()
+		}
+		if eerr, ok := err.(*yfapi.EmptyDataError); ok {
+			msg.Text = eerr.Error()
+		}
+		if ierr, ok := err.(*yfapi.InsufficientDataError); ok {
+			msg.Text = ierr.Error()
+		}
+		return nil
+	}
+
+	equity, err := backtest.EquityBytes(output)
+	if err != nil {
+		msg.Text = backtest.BacktestCaption()
+		log.Println(err)
+		return nil
+	}
+
+	photo := tgbot.NewPhotoUpload(msg.ChatID, equity)
+	photo.Caption = backtest.BacktestCaption()
+	photo.ParseMode = tgbot.ModeMarkdown
+
+	return &photo
+}
+
+// MarketHeatmap returns a photo with a heatmap of symbols or a named symbol list, or fills msg with an error.
+func MarketHeatmap(yfc *yfapi.YFClient, text string, output yfapi.ChartOutput, msg *tgbot.MessageConfig) *tgbot.PhotoConfig {
+	heatmap, err := yfc.GetHeatmap(yfapi.ParseHeatmapSymbols(text))
+	if err != nil {
+		msg.Text = "Unable to get heatmap: " + text
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error() + "\nUsage: /heatmap AAPL MSFT GOOGL or /heatmap DOW"
+		}
+		if eerr, ok := err.(*yfapi.EmptyDataError); ok {
+			msg.Text = eerr.Error()
+		}
+		return nil
+	}
+
+	image, err := heatmap.HeatmapBytes(output)
+	if err != nil {
+		msg.Text = "Unable to draw heatmap: " + text
+		log.Println(err)
+		return nil
+	}
+
+	photo := tgbot.NewPhotoUpload(msg.ChatID, image)
+	photo.Caption = heatmap.HeatmapCaption()
+
+	return &photo
+}
+
+// PriceChart returns a photo with price chart of a symbol over arbitrary dates, or fills msg with an error.
+func PriceChart(yfc *yfapi.YFClient, text string, output yfapi.ChartOutput, msg *tgbot.MessageConfig) *tgbot.PhotoConfig {
+	usage := "Usage: /chart SYMBOL FROM [TO], e.g. /chart AAPL 2020-01-01 2020-12-31"
+	args := strings.Fields(text)
+	if len(args) < 2 || len(args) > 3 {
+		msg.Text = usage
+		return nil
+	}
+
+	from, err := time.Parse("2006-01-02", args[1])
+	if err != nil {
+		msg.Text = usage
+		return nil
+	}
+
+	to := time.Now().UTC()
+	if len(args) == 3 {
+		if to, err = time.Parse("2006-01-02", args[2]); err != nil {
+			msg.Text = usage
+			return nil
+		}
+	}
+
+	period, err := yfapi.NewDateRange(from, to)
+	if err != nil {
+		msg.Text = err.Error() + "\n" + usage
+		return nil
+	}
+
+	params := &yfapi.ChartParams{
+		Symbol:      strings.ToUpper(args[0]),
+		Interval:    period,
+		Measurement: "price",
+		Action:      yfapi.ActionChart,
+		Type:        "hasNoEarnings",
+		Style:       "line",
+		Output:      output,
+	}
+
+	data, err := yfc.GetPriceChart(params.Symbol, params.Interval)
+	if err != nil {
+		msg.Text = fmt.Sprintf("Unable to get chart for symbol: %s", params.Symbol)
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error()
+		}
+		if eerr, ok := err.(*yfapi.EmptyDataError); ok {
+			msg.Text = eerr.Error()
+		}
+		return nil
+	}
+
+	chart, err := data.ChartBytes(params)
+	if err != nil {
+		msg.Text = fmt.Sprintf("Unable to get chart for symbol: %s", params.Symbol)
+		log.Println(err)
+		if eerr, ok := err.(*yfapi.EmptyDataError); ok {
+			msg.Text = eerr.Error()
+		}
+		return nil
+	}
+
+	photo := tgbot.NewPhotoUpload(msg.ChatID, chart)
+	photo.ReplyMarkup = yfapi.ChartKeyboard(params, data.Intervals())
+
+	return &photo
+}
+
+// ExportChart returns a document with price chart in SVG, which stays sharp when zoomed or printed, or fills msg with an error.
+func ExportChart(yfc *yfapi.YFClient, text string, output yfapi.ChartOutput, msg *tgbot.MessageConfig) *tgbot.DocumentConfig {
+	usage := "Usage: /export SYMBOL [PERIOD], e.g. /export AAPL 1y, where PERIOD is one of " + strings.Join(yfapi.PriceRanges, ", ")
+	args := strings.Fields(text)
+	if len(args) < 1 || len(args) > 2 {
+		msg.Text = usage
+		return nil
+	}
+
+	output.Format = "svg"
+	params := &yfapi.ChartParams{
+		Symbol:      strings.ToUpper(args[0]),
+		Interval:    "1mo",
+		Measurement: "price",
+		Action:      yfapi.ActionChart,
+		Type:        "hasNoEarnings",
+		Style:       "line",
+		Output:      output,
+	}
+	if len(args) == 2 {
+		params.Interval = strings.ToLower(args[1])
+		if !yfapi.HasPriceRange(params.Interval) {
+			msg.Text = usage
+			return nil
+		}
+	}
+
+	data, err := yfc.GetPriceChart(params.Symbol, params.Interval)
+	if err != nil {
+		msg.Text = fmt.Sprintf("Unable to get chart for symbol: %s", params.Symbol)
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error()
+		}
+		if eerr, ok := err.(*yfapi.EmptyDataError); ok {
+			msg.Text = eerr.Error()
+		}
+		return nil
+	}
+
+	chart, err := data.ChartBytes(params)
+	if err != nil {
+		msg.Text = fmt.Sprintf("Unable to get chart for symbol: %s", params.Symbol)
+		log.Println(err)
+		if eerr, ok := err.(*yfapi.EmptyDataError); ok {
+			msg.Text = eerr.Error()
+		}
+		return nil
+	}
+
+	document := tgbot.NewDocumentUpload(msg.ChatID, chart)
+
+	return &document
+}
+
+// SetChartTheme changes colors of charts rendered for the user.
+func SetChartTheme(text string, output *yfapi.ChartOutput, msg *tgbot.MessageConfig) {
+	theme := strings.ToLower(strings.TrimSpace(text))
+	if !yfapi.HasChartTheme(theme) {
+		msg.Text = "Usage: /theme " + strings.Join(yfapi.ChartThemes(), "|")
+		return
+	}
+
+	output.Theme = theme
+	msg.Text = fmt.Sprintf("Charts will be drawn with %s theme", theme)
+}
+
+// SetChartScale changes dimensions of charts rendered for the user, larger charts are sharper on high-density screens.
+func SetChartScale(text string, output *yfapi.ChartOutput, msg *tgbot.MessageConfig) {
+	scale, ok := yfapi.ParseChartScale(strings.TrimSpace(text))
+	if !ok {
+		msg.Text = "Usage: /size SCALE, where SCALE is from 1 to 3, e.g. /size 2"
+		return
+	}
+
+	output.Scale = scale
+	msg.Text = fmt.Sprintf("Charts will be %gx larger", scale)
+}
+
+// SetQuoteFormat changes how quotes are sent to the user: as a text message or as a rendered card.
+func SetQuoteFormat(text string, user *UserSettings, msg *tgbot.MessageConfig) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "text":
+		user.QuoteCards = false
+		msg.Text = "Quotes will be sent as text"
+	case "card":
+		user.QuoteCards = true
+		msg.Text = "Quotes will be sent as cards"
+	default:
+		msg.Text = "Usage: /format text|card"
+	}
+}
+
+// QuoteCard returns a photo with a quote card of a symbol, or fills msg with an error.
+// Nil is returned with empty msg when the card can not be rendered, so the quote is sent as text instead.
+func QuoteCard(yfc *yfapi.YFClient, symbol string, output yfapi.ChartOutput, msg *tgbot.MessageConfig) *tgbot.PhotoConfig {
+	quote, err := yfc.GetQuote(symbol)
+	if err != nil {
+		msg.Text = fmt.Sprintf("Unable to get data for symbol: %s", symbol)
+		log.Println(err)
+		if qerr, ok := err.(*yfapi.QueryError); ok {
+			msg.Text = qerr.Error()
+		}
+		return nil
+	}
+
+	if quote.StandardMessage() == "" {
+		return nil
+	}
+
+	// the card is rendered without a sparkline when there is no price history
+	spark, err := yfc.GetPriceChart(quote.Symbol(), yfapi.SparklinePeriod)
+	if err != nil {
+		log.Println(err)
+		spark = nil
+	}
+
+	card, err := quote.CardBytes(spark, output)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	photo := tgbot.NewPhotoUpload(msg.ChatID, card)
+	photo.Caption = quote.CardCaption()
+	photo.ReplyMarkup = quote.StandardMessageInlineKeyboard()
+
+	return &photo
+}
+
+// SendChartAlbum sends price charts of several ranges as a single media group.
+func SendChartAlbum(bot *tgbot.BotAPI, yfc *yfapi.YFClient, charts *yfapi.ChartCache, params *yfapi.ChartParams, chatID int64) error {
+	album, err := yfc.GetChartAlbum(params, charts)
+	if err != nil {
+		return err
+	}
+
+	p, files := yfapi.NewMediaGroupParams(chatID, album)
+	return helpers.Retry(3, func() error {
+		resp, err := helpers.UploadFiles(bot, "sendMediaGroup", p, files)
+		if err != nil {
+			return err
+		}
+
+		var sent []tgbot.Message
+		if err := json.Unmarshal(resp.Result, &sent); err == nil && len(sent) == len(album) {
+			for i, c := range album {
+				charts.SetFileID(c.Params, sent[i])
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,325 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"quote-telegram-bot/pkg/ta"
+	"quote-telegram-bot/pkg/yfapi"
+)
+
+// initialEquity is the amount both a strategy and buy and hold start with
+const initialEquity = 100
+
+// Strategy decides whether to hold a symbol after each close.
+type Strategy interface {
+	Name() string
+	// WarmUp returns a number of closes indicators of the strategy need before the first position can be taken.
+	WarmUp() int
+	// Positions returns 1 for closes after which the symbol is held and 0 otherwise.
+	// The strategy is out of the market until its indicators can be calculated.
+	Positions(closes []float64) []float64
+}
+
+// strategyParams are numbers of parameters of built-in strategies
+var strategyParams = map[string]int{
+	"hold": 0,
+	"sma":  2,
+	"rsi":  3,
+}
+
+type buyAndHold struct{}
+
+func (buyAndHold) Name() string {
+	return "Buy and hold"
+}
+
+func (buyAndHold) WarmUp() int {
+	return 0
+}
+
+func (buyAndHold) Positions(closes []float64) []float64 {
+	positions := make([]float64, len(closes))
+	for i := range positions {
+		positions[i] = 1
+	}
+
+	return positions
+}
+
+// maCrossover holds the symbol while the fast SMA is above the slow one.
+type maCrossover struct {
+	Fast int
+	Slow int
+}
+
+func (s maCrossover) Name() string {
+	return fmt.Sprintf("SMA %d/%d crossover", s.Fast, s.Slow)
+}
+
+func (s maCrossover) WarmUp() int {
+	return s.Slow
+}
+
+func (s maCrossover) Positions(closes []float64) []float64 {
+	fast, slow := ta.SMA(closes, s.Fast), ta.SMA(closes, s.Slow)
+	positions := make([]float64, len(closes))
+	for i := range closes {
+		// comparisons with NaN are false, so there is no position before both averages are calculated
+		if fast[i] > slow[i] {
+			positions[i] = 1
+		}
+	}
+
+	return positions
+}
+
+// rsiThresholds buys when RSI falls below the low threshold and sells when it rises above the high one.
+type rsiThresholds struct {
+	Period int
+	Low    float64
+	High   float64
+}
+
+func (s rsiThresholds) Name() string {
+	return fmt.Sprintf("RSI(%d) %g/%g", s.Period, s.Low, s.High)
+}
+
+func (s rsiThresholds) WarmUp() int {
+	return s.Period + 1
+}
+
+func (s rsiThresholds) Positions(closes []float64) []float64 {
+	rsi := ta.RSI(closes, s.Period)
+	positions := make([]float64, len(closes))
+	held := 0.0
+	for i, v := range rsi {
+		switch {
+		case v < s.Low:
+			held = 1
+		case v > s.High:
+			held = 0
+		}
+		positions[i] = held
+	}
+
+	return positions
+}
+
+// NewStrategy returns a built-in strategy by name with its numeric parameters, defaults are used for missing ones:
+//
+//	hold
+//	sma [FAST [SLOW]], 50 and 200 by default
+//	rsi [PERIOD [LOW [HIGH]]], 14, 30 and 70 by default
+func NewStrategy(name string, params []float64) (Strategy, error) {
+	param := func(i int, def float64) float64 {
+		if i < len(params) {
+			return params[i]
+		}
+		return def
+	}
+
+	var strategy Strategy
+	switch strings.ToLower(name) {
+	case "hold":
+		strategy = buyAndHold{}
+	case "sma":
+		s := maCrossover{Fast: int(param(0, 50)), Slow: int(param(1, 200))}
+		if s.Fast < 1 || s.Slow <= s.Fast {
+			return nil, &yfapi.QueryError{Code: "Bad Request", Description: "Fast SMA period must be less than slow one"}
+		}
+		strategy = s
+	case "rsi":
+		s := rsiThresholds{Period: int(param(0, 14)), Low: param(1, 30), High: param(2, 70)}
+		if s.Period < 2 || s.Low < 0 || s.High > 100 || s.Low >= s.High {
+			return nil, &yfapi.QueryError{Code: "Bad Request", Description: "RSI thresholds must be from 0 to 100, low below high"}
+		}
+		strategy = s
+	default:
+		return nil, &yfapi.QueryError{Code: "Bad Request", Description: "Unknown strategy: " + name}
+	}
+
+	if len(params) > StrategyParams(name) {
+		return nil, &yfapi.QueryError{Code: "Bad Request", Description: fmt.Sprintf("Too many parameters of %s", strategy.Name())}
+	}
+
+	return strategy, nil
+}
+
+// StrategyParams returns a number of numeric parameters a built-in strategy takes, zero for unknown strategies.
+func StrategyParams(name string) int {
+	return strategyParams[strings.ToLower(name)]
+}
+
+// defaultBacktestPeriod is how far back strategies are tested when no start is given
+const defaultBacktestPeriod = "10y"
+
+// ParseBacktestArgs parses a strategy with its parameters followed by an optional start of the backtest:
+// a range, a date or a year. A year from 1900 to the current one is always taken as the start,
+// so strategies can not be given parameters like that.
+func ParseBacktestArgs(args []string, now time.Time) (Strategy, string, error) {
+	if len(args) == 0 {
+		return nil, "", &yfapi.QueryError{Code: "Bad Request", Description: "Provide a strategy"}
+	}
+
+	name, period := args[0], defaultBacktestPeriod
+	args = args[1:]
+	if n := len(args); n > 0 {
+		last := strings.ToLower(args[n-1])
+		from, err := time.Parse("2006-01-02", last)
+		if err != nil {
+			if year, yerr := strconv.Atoi(last); yerr == nil && len(last) == 4 && year >= 1900 && year <= now.Year() {
+				from, err = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), nil
+			}
+		}
+		if err == nil {
+			if period, err = yfapi.NewDateRange(from, now); err != nil {
+				return nil, "", err
+			}
+			args = args[:n-1]
+		} else if yfapi.HasStatsRange(last) {
+			period, args = last, args[:n-1]
+		}
+	}
+
+	params := make([]float64, 0, len(args))
+	for _, arg := range args {
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, "", &yfapi.QueryError{Code: "Bad Request", Description: "Strategy parameters must be numbers: " + arg}
+		}
+		params = append(params, v)
+	}
+
+	strategy, err := NewStrategy(name, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return strategy, period, nil
+}
+
+// Backtest is a simulation of a strategy over daily closes compared to buy and hold.
+// Positions are taken at the close a signal is given on, there are no trading costs.
+type Backtest struct {
+	Symbol   string
+	Strategy string
+	Dates    []time.Time
+	// Equity and Hold are values of initialEquity invested in the strategy and held respectively
+	Equity []float64
+	Hold   []float64
+
+	CAGR            float64
+	MaxDrawdown     float64
+	HoldCAGR        float64
+	HoldMaxDrawdown float64
+	// Trades is a number of entries into the market
+	Trades int
+	// Exposure is a share of days in the market in percent
+	Exposure float64
+}
+
+// GetBacktest fetches daily closes of a symbol, along with history preceding the period the strategy warms up on,
+// and runs the strategy over them.
+func GetBacktest(yfc *yfapi.YFClient, symbol, period string, strategy Strategy) (*Backtest, error) {
+	data, err := yfc.GetDailyChartWithLookback(symbol, period, strategy.WarmUp())
+	if err != nil {
+		return nil, err
+	}
+
+	return RunBacktest(NewSeries(data), strategy)
+}
+
+// RunBacktest simulates the strategy: the return of each day is earned if the symbol was held after the previous close.
+// Indicators of the strategy are calculated over history of the series too, so it may trade from the first close.
+// Series too short for the strategy to trade for minStatsDays are rejected, rather than reported as never traded.
+func RunBacktest(s Series, strategy Strategy) (*Backtest, error) {
+	warmUp := strategy.WarmUp() - len(s.History)
+	if warmUp < 0 {
+		warmUp = 0
+	}
+	if len(s.Closes)-warmUp < minStatsDays {
+		return nil, &yfapi.InsufficientDataError{
+			Symbol:   s.Symbol,
+			Days:     strategy.WarmUp() - warmUp + len(s.Closes),
+			Required: strategy.WarmUp() + minStatsDays,
+		}
+	}
+
+	closes := make([]float64, 0, len(s.History)+len(s.Closes))
+	closes = append(append(closes, s.History...), s.Closes...)
+	positions := strategy.Positions(closes)[len(s.History):]
+	b := &Backtest{
+		Symbol:   s.Symbol,
+		Strategy: strategy.Name(),
+		Dates:    s.Dates,
+		Equity:   make([]float64, len(s.Closes)),
+		Hold:     make([]float64, len(s.Closes)),
+	}
+
+	b.Equity[0], b.Hold[0] = initialEquity, initialEquity
+	held := 0
+	for i := 1; i < len(s.Closes); i++ {
+		r := s.Closes[i]/s.Closes[i-1] - 1
+		b.Equity[i] = b.Equity[i-1] * (1 + positions[i-1]*r)
+		b.Hold[i] = initialEquity * s.Closes[i] / s.Closes[0]
+		if positions[i-1] > 0 {
+			held++
+		}
+	}
+
+	for i, p := range positions[:len(positions)-1] {
+		if p > 0 && (i == 0 || positions[i-1] == 0) {
+			b.Trades++
+		}
+	}
+	b.Exposure = float64(held) / float64(len(s.Closes)-1) * 100
+
+	equity := Series{Symbol: s.Symbol, Dates: s.Dates, Closes: b.Equity}
+	hold := Series{Symbol: s.Symbol, Dates: s.Dates, Closes: b.Hold}
+	b.CAGR, b.HoldCAGR = equity.annualReturn(), hold.annualReturn()
+	dd, _, trough := drawdown(b.Equity)
+	b.MaxDrawdown = dd[trough]
+	dd, _, trough = drawdown(b.Hold)
+	b.HoldMaxDrawdown = dd[trough]
+
+	return b, nil
+}
+
+// BacktestCaption formats results of the strategy next to buy and hold as a monospace table.
+func (b *Backtest) BacktestCaption() string {
+	value := func(v float64) string {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "N/A"
+		}
+		return fmt.Sprintf("%+.2f%%", v)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*%s: %s*\n```\n", b.Symbol, b.Strategy))
+	sb.WriteString(fmt.Sprintf("%-13s %10s %10s\n", "", "Strategy", "Hold"))
+	sb.WriteString(fmt.Sprintf("%-13s %10s %10s\n", "CAGR", value(b.CAGR), value(b.HoldCAGR)))
+	sb.WriteString(fmt.Sprintf("%-13s %10s %10s\n", "Max drawdown", value(b.MaxDrawdown), value(b.HoldMaxDrawdown)))
+	sb.WriteString(fmt.Sprintf("%-13s %10.0f %10.0f\n", "Final equity", b.Equity[len(b.Equity)-1], b.Hold[len(b.Hold)-1]))
+	sb.WriteString(fmt.Sprintf("%-13s %10d\n", "Trades", b.Trades))
+	sb.WriteString(fmt.Sprintf("%-13s %9.0f%%\n", "In market", b.Exposure))
+	sb.WriteString("```\n")
+	sb.WriteString(fmt.Sprintf("_Daily closes from %s to %s, no trading costs_",
+		b.Dates[0].Format("2006-01-02"), b.Dates[len(b.Dates)-1].Format("2006-01-02")))
+
+	return sb.String()
+}
+
+// EquityBytes plots equity of the strategy against buy and hold.
+func (b *Backtest) EquityBytes(o yfapi.ChartOutput) (tgbot.FileBytes, error) {
+	curves := []yfapi.Curve{{Name: b.Strategy, Values: b.Equity}}
+	if b.Strategy != (buyAndHold{}).Name() {
+		curves = append(curves, yfapi.Curve{Name: "Buy and hold", Values: b.Hold})
+	}
+
+	return yfapi.EquityChartBytes(fmt.Sprintf("%s: %s", b.Symbol, b.Strategy), b.Dates, curves, o)
+}
@@ -0,0 +1,95 @@
+package analytics
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"quote-telegram-bot/pkg/yfapi"
+)
+
+func TestRunBacktestWarmUp(t *testing.T) {
+	strategy, err := NewStrategy("sma", []float64{5, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// steadily rising closes keep the fast average above the slow one
+	all := closes(40)
+	dates := everyDay(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 30)
+
+	tests := []struct {
+		name     string
+		series   Series
+		exposure float64
+		err      bool
+	}{
+		{"with history", Series{Symbol: "UP", Dates: dates, Closes: all[10:], History: all[:10]}, 100, false},
+		{"without history", Series{Symbol: "UP", Dates: dates, Closes: all[10:]}, float64(30-10) / 29 * 100, false},
+		{"too short", Series{Symbol: "UP", Dates: dates[:25], Closes: all[15:]}, 0, true},
+	}
+
+	for _, tt := range tests {
+		b, err := RunBacktest(tt.series, strategy)
+		if tt.err {
+			if _, ok := err.(*yfapi.InsufficientDataError); !ok {
+				t.Errorf("%s: error = %v, want InsufficientDataError", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if b.Trades != 1 || math.Abs(b.Exposure-tt.exposure) > epsilon {
+			t.Errorf("%s: trades = %d, exposure = %v, want 1 and %v", tt.name, b.Trades, b.Exposure, tt.exposure)
+		}
+	}
+}
+
+func TestStrategyParams(t *testing.T) {
+	for name, want := range map[string]int{"hold": 0, "SMA": 2, "rsi": 3, "unknown": 0} {
+		if got := StrategyParams(name); got != want {
+			t.Errorf("StrategyParams(%q) = %d, want %d", name, got, want)
+		}
+	}
+	if _, err := NewStrategy("sma", []float64{50, 200, 2010}); err == nil {
+		t.Error("NewStrategy() with 3 SMA parameters error = nil")
+	}
+}
+
+func TestParseBacktestArgs(t *testing.T) {
+	now := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	since2010 := yfapi.FormatDateRange(time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), now)
+	tests := []struct {
+		args     string
+		strategy Strategy
+		period   string
+	}{
+		{"hold", buyAndHold{}, "10y"},
+		{"sma 2010", maCrossover{Fast: 50, Slow: 200}, since2010},
+		{"sma 50 200 2010", maCrossover{Fast: 50, Slow: 200}, since2010},
+		{"sma 20 100 5y", maCrossover{Fast: 20, Slow: 100}, "5y"},
+		{"rsi 2010", rsiThresholds{Period: 14, Low: 30, High: 70}, since2010},
+		{"rsi 10 20 80 2010-01-01", rsiThresholds{Period: 10, Low: 20, High: 80}, since2010},
+		{"sma 20 1000", maCrossover{Fast: 20, Slow: 1000}, "10y"},
+	}
+
+	for _, tt := range tests {
+		strategy, period, err := ParseBacktestArgs(strings.Fields(tt.args), now)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.args, err)
+			continue
+		}
+		if strategy != tt.strategy || period != tt.period {
+			t.Errorf("%s: strategy = %#v, period = %s, want %#v and %s", tt.args, strategy, period, tt.strategy, tt.period)
+		}
+	}
+
+	// numbers which are neither parameters nor a year are rejected
+	for _, args := range []string{"sma 50 200 2030", "rsi 14 30 70 20", "sma 50 x", "unknown 2010", ""} {
+		if _, _, err := ParseBacktestArgs(strings.Fields(args), now); err == nil {
+			t.Errorf("%s: error = nil", args)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"quote-telegram-bot/pkg/yfapi"
+)
+
+const maxCorrelatedSymbols = 10
+
+// Correlation is a matrix of pairwise correlations of daily returns, Matrix[i][j] is the correlation of
+// Symbols[i] and Symbols[j]. Returns are taken over days every symbol was traded.
+type Correlation struct {
+	Symbols []string
+	Period  string
+	Matrix  [][]float64
+	From    time.Time
+	To      time.Time
+	Days    int
+}
+
+// GetCorrelation fetches daily closes of the symbols and correlates their returns over the period.
+func GetCorrelation(yfc *yfapi.YFClient, symbols []string, period string) (*Correlation, error) {
+	if len(symbols) < 2 || len(symbols) > maxCorrelatedSymbols {
+		return nil, &yfapi.QueryError{
+			Code:        "Bad Request",
+			Description: fmt.Sprintf("Provide from 2 to %d symbols", maxCorrelatedSymbols),
+		}
+	}
+
+	series := make([]Series, 0, len(symbols))
+	for _, symbol := range symbols {
+		data, err := yfc.GetDailyChart(symbol, period)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, NewSeries(data))
+	}
+
+	return ComputeCorrelation(series, period)
+}
+
+// ComputeCorrelation aligns closes of the series by date and calculates Pearson correlations of their returns.
+// Symbols trading on different calendars, e.g. stocks and crypto, are compared over common days:
+// returns span from one common close to the next, so weekends and holidays are not lost.
+func ComputeCorrelation(series []Series, period string) (*Correlation, error) {
+	if len(series) < 2 {
+		return nil, &yfapi.QueryError{
+			Code:        "Bad Request",
+			Description: "Provide at least 2 symbols to correlate",
+		}
+	}
+
+	aligned := Align(series...)
+	days := len(aligned[0].Dates)
+	if days < minStatsDays {
+		return nil, &yfapi.InsufficientDataError{Symbol: symbolsOf(series), Days: days, Required: minStatsDays}
+	}
+
+	returns := make([][]float64, len(aligned))
+	for i, s := range aligned {
+		returns[i] = s.Returns()
+	}
+
+	c := &Correlation{
+		Symbols: make([]string, len(series)),
+		Period:  period,
+		Matrix:  make([][]float64, len(series)),
+		From:    aligned[0].Dates[0],
+		To:      aligned[0].Dates[days-1],
+		Days:    days,
+	}
+	for i := range returns {
+		c.Symbols[i] = series[i].Symbol
+		c.Matrix[i] = make([]float64, len(returns))
+		for j := range returns {
+			c.Matrix[i][j] = pearson(returns[i], returns[j])
+		}
+	}
+
+	return c, nil
+}
+
+// pearson returns correlation of two equally long slices, NaN if either of them is constant.
+func pearson(a, b []float64) float64 {
+	r := covariance(a, b) / (stdDev(a) * stdDev(b))
+	if math.IsInf(r, 0) {
+		return math.NaN()
+	}
+
+	return math.Max(-1, math.Min(r, 1))
+}
+
+func symbolsOf(series []Series) string {
+	symbols := make([]string, 0, len(series))
+	for _, s := range series {
+		symbols = append(symbols, s.Symbol)
+	}
+
+	return strings.Join(symbols, ", ")
+}
+
+// MatrixBytes renders the correlation matrix.
+func (c *Correlation) MatrixBytes(o yfapi.ChartOutput) (tgbot.FileBytes, error) {
+	return yfapi.MatrixBytes(fmt.Sprintf("Correlation of daily returns (%s)", c.Period), c.Symbols, c.Matrix, o)
+}
+
+func (c *Correlation) CorrelationCaption() string {
+	return fmt.Sprintf("%d common trading days from %s to %s", c.Days, c.From.Format("2006-01-02"), c.To.Format("2006-01-02"))
+}
@@ -0,0 +1,89 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"quote-telegram-bot/pkg/yfapi"
+)
+
+func TestPearson(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		{"same", []float64{1, 2, 3, 4}, []float64{1, 2, 3, 4}, 1},
+		{"scaled", []float64{1, 2, 3, 4}, []float64{10, 20, 30, 40}, 1},
+		{"opposite", []float64{1, 2, 3, 4}, []float64{4, 3, 2, 1}, -1},
+		{"uncorrelated", []float64{1, -1, 1, -1}, []float64{1, 1, -1, -1}, 0},
+		{"constant", []float64{1, 1, 1, 1}, []float64{1, 2, 3, 4}, math.NaN()},
+		{"both constant", []float64{0, 0, 0}, []float64{0, 0, 0}, math.NaN()},
+		{"too short", []float64{1}, []float64{1}, math.NaN()},
+	}
+
+	for _, tt := range tests {
+		got := pearson(tt.a, tt.b)
+		if math.IsNaN(tt.want) {
+			if !math.IsNaN(got) {
+				t.Errorf("%s: pearson() = %v, want NaN", tt.name, got)
+			}
+			continue
+		}
+		if math.Abs(got-tt.want) > epsilon {
+			t.Errorf("%s: pearson() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComputeCorrelation(t *testing.T) {
+	stockDates := weekdays(time.Date(2024, 1, 1, 9, 30, 0, 0, newYork), 40)
+	cryptoDates := everyDay(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 56)
+	stock := Series{Symbol: "SPY", Dates: stockDates, Closes: walk(zigzag(39, 1))}
+	crypto := Series{Symbol: "BTC-USD", Dates: cryptoDates, Closes: closes(56)}
+
+	c, err := ComputeCorrelation([]Series{stock, crypto, stock}, "3mo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Days != 40 {
+		t.Errorf("Days = %d, want 40 common days", c.Days)
+	}
+	for i := range c.Matrix {
+		if math.Abs(c.Matrix[i][i]-1) > epsilon {
+			t.Errorf("Matrix[%d][%d] = %v, want 1", i, i, c.Matrix[i][i])
+		}
+		for j := range c.Matrix {
+			if c.Matrix[i][j] != c.Matrix[j][i] {
+				t.Errorf("Matrix[%d][%d] = %v, Matrix[%d][%d] = %v, want symmetric", i, j, c.Matrix[i][j], j, i, c.Matrix[j][i])
+			}
+		}
+	}
+	if math.Abs(c.Matrix[0][2]-1) > epsilon {
+		t.Errorf("correlation of the same series = %v, want 1", c.Matrix[0][2])
+	}
+}
+
+func TestComputeCorrelationInsufficientData(t *testing.T) {
+	// calendars of the series barely overlap
+	a := Series{Symbol: "A", Dates: everyDay(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 30), Closes: closes(30)}
+	b := Series{Symbol: "B", Dates: everyDay(time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC), 30), Closes: closes(30)}
+
+	_, err := ComputeCorrelation([]Series{a, b}, "1mo")
+	if ierr, ok := err.(*yfapi.InsufficientDataError); !ok || ierr.Days != 6 {
+		t.Errorf("error = %v, want InsufficientDataError of 6 days", err)
+	}
+}
+
+func TestComputeCorrelationTooFewSeries(t *testing.T) {
+	one := Series{Symbol: "SPY", Dates: everyDay(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 30), Closes: closes(30)}
+
+	for _, series := range [][]Series{nil, {one}} {
+		if _, err := ComputeCorrelation(series, "1mo"); err == nil {
+			t.Errorf("ComputeCorrelation() of %d series: no error", len(series))
+		} else if _, ok := err.(*yfapi.QueryError); !ok {
+			t.Errorf("ComputeCorrelation() of %d series: error = %v, want QueryError", len(series), err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Package analytics calculates statistics over price history fetched with yfapi.
+// Results plotted from chart buttons implement yfapi.Chartable, so they are rendered and cached like other charts.
+package analytics
+
+import (
+	"math"
+	"time"
+
+	"quote-telegram-bot/pkg/yfapi"
+)
+
+// Series is a sequence of daily closes of a symbol.
+type Series struct {
+	Symbol string
+	Dates  []time.Time
+	Closes []float64
+	// History are closes preceding the series, e.g. to warm up indicators of a strategy
+	History []float64
+}
+
+// NewSeries takes closes of bars with trades from the chart, lookback bars of the chart are kept as history.
+func NewSeries(c *yfapi.Chart) Series {
+	dates, closes := c.Closes()
+
+	return Series{
+		Symbol:  c.Meta.Symbol,
+		Dates:   dates,
+		Closes:  closes,
+		History: c.LookbackCloses(),
+	}
+}
+
+// Returns returns simple returns between consecutive closes, one less than closes.
+func (s Series) Returns() []float64 {
+	if len(s.Closes) < 2 {
+		return nil
+	}
+
+	returns := make([]float64, len(s.Closes)-1)
+	for i := 1; i < len(s.Closes); i++ {
+		returns[i-1] = s.Closes[i]/s.Closes[i-1] - 1
+	}
+
+	return returns
+}
+
+// periodsPerYear estimates how many bars a year of the series has, e.g. about 252 for stocks and 365 for crypto.
+func (s Series) periodsPerYear() float64 {
+	return float64(len(s.Dates)-1) / s.years()
+}
+
+func (s Series) years() float64 {
+	return s.Dates[len(s.Dates)-1].Sub(s.Dates[0]).Hours() / 24 / 365.25
+}
+
+// Align keeps closes of dates every series has, so returns of different symbols cover the same days.
+// Dates are compared by calendar day in the exchange time zone, as exchanges close at different times.
+func Align(series ...Series) []Series {
+	day := func(t time.Time) string {
+		return t.Format("2006-01-02")
+	}
+
+	counts := make(map[string]int)
+	for _, s := range series {
+		seen := make(map[string]struct{}, len(s.Dates))
+		for _, d := range s.Dates {
+			if _, ok := seen[day(d)]; !ok {
+				seen[day(d)] = struct{}{}
+				counts[day(d)]++
+			}
+		}
+	}
+
+	aligned := make([]Series, len(series))
+	for i, s := range series {
+		a := Series{Symbol: s.Symbol}
+		for j, d := range s.Dates {
+			// the last close of a day wins when a series has several bars of it
+			if n := len(a.Dates); n > 0 && day(a.Dates[n-1]) == day(d) {
+				a.Closes[n-1] = s.Closes[j]
+				continue
+			}
+			if counts[day(d)] == len(series) {
+				a.Dates = append(a.Dates, d)
+				a.Closes = append(a.Closes, s.Closes[j])
+			}
+		}
+		aligned[i] = a
+	}
+
+	return aligned
+}
+
+// annualReturn returns compound annual growth rate of closes in percent.
+func (s Series) annualReturn() float64 {
+	return (math.Pow(s.Closes[len(s.Closes)-1]/s.Closes[0], 1/s.years()) - 1) * 100
+}
+
+// drawdown returns decline from the running peak at each close in percent,
+// along with indices of the peak and the trough of the deepest decline.
+func drawdown(closes []float64) (dd []float64, peak, trough int) {
+	dd = make([]float64, len(closes))
+	high := 0
+	for i, c := range closes {
+		if c > closes[high] {
+			high = i
+		}
+		dd[i] = (c/closes[high] - 1) * 100
+		if dd[i] < dd[trough] {
+			peak, trough = high, i
+		}
+	}
+
+	return dd, peak, trough
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum / float64(len(values))
+}
+
+// stdDev returns sample standard deviation.
+func stdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return math.NaN()
+	}
+
+	m, sum := mean(values), 0.0
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+// covariance returns sample covariance of two equally long slices.
+func covariance(a, b []float64) float64 {
+	if len(a) < 2 || len(a) != len(b) {
+		return math.NaN()
+	}
+
+	ma, mb, sum := mean(a), mean(b), 0.0
+	for i := range a {
+		sum += (a[i] - ma) * (b[i] - mb)
+	}
+
+	return sum / float64(len(a)-1)
+}
@@ -0,0 +1,145 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const epsilon = 1e-9
+
+var newYork, _ = time.LoadLocation("America/New_York")
+
+// weekdays returns n dates of a stock exchange calendar at the open, skipping weekends.
+func weekdays(from time.Time, n int) []time.Time {
+	dates := make([]time.Time, 0, n)
+	for d := from; len(dates) < n; d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			dates = append(dates, d)
+		}
+	}
+
+	return dates
+}
+
+// everyDay returns n dates of a crypto calendar at midnight UTC.
+func everyDay(from time.Time, n int) []time.Time {
+	dates := make([]time.Time, n)
+	for i := range dates {
+		dates[i] = from.AddDate(0, 0, i)
+	}
+
+	return dates
+}
+
+func closes(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = float64(100 + i)
+	}
+
+	return values
+}
+
+func TestAlign(t *testing.T) {
+	// 2024-01-01 is Monday
+	stock := Series{Symbol: "SPY", Dates: weekdays(time.Date(2024, 1, 1, 9, 30, 0, 0, newYork), 10), Closes: closes(10)}
+	crypto := Series{Symbol: "BTC-USD", Dates: everyDay(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 14), Closes: closes(14)}
+
+	aligned := Align(stock, crypto)
+	if len(aligned) != 2 {
+		t.Fatalf("len = %d, want 2", len(aligned))
+	}
+	if len(aligned[0].Dates) != 10 || len(aligned[1].Dates) != 10 {
+		t.Fatalf("aligned lengths = %d, %d, want 10, 10", len(aligned[0].Dates), len(aligned[1].Dates))
+	}
+	for i := range aligned[0].Dates {
+		a, b := aligned[0].Dates[i].Format("2006-01-02"), aligned[1].Dates[i].Format("2006-01-02")
+		if a != b {
+			t.Errorf("date[%d] = %s and %s, want the same day", i, a, b)
+		}
+		if aligned[1].Dates[i].Weekday() == time.Saturday || aligned[1].Dates[i].Weekday() == time.Sunday {
+			t.Errorf("date[%d] = %s is a weekend", i, b)
+		}
+	}
+	// crypto closes of Monday 2024-01-08 follow the weekend, which is skipped
+	if aligned[1].Closes[5] != 107 {
+		t.Errorf("crypto close of 2024-01-08 = %v, want 107", aligned[1].Closes[5])
+	}
+	if aligned[0].Symbol != "SPY" || aligned[1].Symbol != "BTC-USD" {
+		t.Errorf("symbols = %s, %s", aligned[0].Symbol, aligned[1].Symbol)
+	}
+}
+
+func TestAlignDuplicateDays(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	// the latest bar of a day is often reported along with the previous daily bar of the same day
+	a := Series{
+		Symbol: "A",
+		Dates:  []time.Time{day, day.AddDate(0, 0, 1), day.AddDate(0, 0, 1).Add(6 * time.Hour), day.AddDate(0, 0, 2)},
+		Closes: []float64{1, 2, 3, 4},
+	}
+	b := Series{Symbol: "B", Dates: everyDay(day, 3), Closes: []float64{10, 20, 30}}
+
+	aligned := Align(a, b)
+	want := []float64{1, 3, 4}
+	if len(aligned[0].Closes) != len(want) {
+		t.Fatalf("closes = %v, want %v", aligned[0].Closes, want)
+	}
+	for i := range want {
+		if aligned[0].Closes[i] != want[i] {
+			t.Errorf("closes = %v, want %v", aligned[0].Closes, want)
+			break
+		}
+	}
+	if len(aligned[1].Closes) != 3 {
+		t.Errorf("closes of B = %v, want 3 closes", aligned[1].Closes)
+	}
+}
+
+func TestPeriodsPerYear(t *testing.T) {
+	tests := []struct {
+		name  string
+		dates []time.Time
+		want  float64
+	}{
+		{"stock", weekdays(time.Date(2023, 1, 2, 9, 30, 0, 0, newYork), 261), 261},
+		{"crypto", everyDay(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), 366), 365},
+	}
+
+	for _, tt := range tests {
+		s := Series{Dates: tt.dates, Closes: closes(len(tt.dates))}
+		// weekends at the ends of the year shift the span slightly
+		if got := s.periodsPerYear(); math.Abs(got-tt.want) > 2 {
+			t.Errorf("%s: periodsPerYear() = %v, want about %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDrawdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		closes []float64
+		want   []float64
+		peak   int
+		trough int
+	}{
+		{"rising", []float64{1, 2, 3}, []float64{0, 0, 0}, 0, 0},
+		{"single decline", []float64{100, 50, 75}, []float64{0, -50, -25}, 0, 1},
+		{"deepest after new peak", []float64{100, 90, 200, 100, 150}, []float64{0, -10, 0, -50, -25}, 2, 3},
+		{"falling", []float64{100, 80, 60}, []float64{0, -20, -40}, 0, 2},
+	}
+
+	for _, tt := range tests {
+		dd, peak, trough := drawdown(tt.closes)
+		if peak != tt.peak || trough != tt.trough {
+			t.Errorf("%s: peak, trough = %d, %d, want %d, %d", tt.name, peak, trough, tt.peak, tt.trough)
+		}
+		for i := range tt.want {
+			if math.Abs(dd[i]-tt.want[i]) > epsilon {
+				t.Errorf("%s: drawdown = %v, want %v", tt.name, dd, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"quote-telegram-bot/pkg/yfapi"
+)
+
+// minStatsDays is the least number of daily closes statistics are calculated over
+const minStatsDays = 20
+
+// Stats are risk and performance statistics of a symbol over a period. Ratios are annualized,
+// returns and drawdowns are in percent.
+type Stats struct {
+	Symbol string
+	Period string
+	From   time.Time
+	To     time.Time
+
+	TotalReturn  float64
+	AnnualReturn float64
+	Volatility   float64
+	Sharpe       float64
+	Sortino      float64
+
+	MaxDrawdown float64
+	// MaxDrawdownPeak and MaxDrawdownTrough are dates of the deepest decline
+	MaxDrawdownPeak   time.Time
+	MaxDrawdownTrough time.Time
+
+	// Benchmark is empty and Beta is NaN when stats are calculated without a benchmark
+	Benchmark string
+	Beta      float64
+
+	BestDay      float64
+	BestDayDate  time.Time
+	WorstDay     float64
+	WorstDayDate time.Time
+
+	// Dates and Drawdown are decline from the running peak at each close
+	Dates    []time.Time
+	Drawdown []float64
+}
+
+// ComputeStats calculates statistics of daily returns. Benchmark is optional, pass a Series with no dates to skip beta.
+// riskFree is an annual rate in percent Sharpe and Sortino ratios are calculated over.
+func ComputeStats(asset Series, benchmark Series, period string, riskFree float64) (*Stats, error) {
+	if len(asset.Closes) < minStatsDays {
+		return nil, &yfapi.InsufficientDataError{Symbol: asset.Symbol, Days: len(asset.Closes), Required: minStatsDays}
+	}
+
+	returns := asset.Returns()
+	perYear := asset.periodsPerYear()
+	s := &Stats{
+		Symbol:      asset.Symbol,
+		Period:      period,
+		From:        asset.Dates[0],
+		To:          asset.Dates[len(asset.Dates)-1],
+		TotalReturn: (asset.Closes[len(asset.Closes)-1]/asset.Closes[0] - 1) * 100,
+		Volatility:  stdDev(returns) * math.Sqrt(perYear) * 100,
+		Beta:        math.NaN(),
+	}
+	s.AnnualReturn = asset.annualReturn()
+
+	// excess returns are measured over the daily share of the risk-free rate
+	rf := riskFree / 100 / perYear
+	excess, downside := 0.0, 0.0
+	for _, r := range returns {
+		excess += r - rf
+		if r < rf {
+			downside += (r - rf) * (r - rf)
+		}
+	}
+	excess /= float64(len(returns))
+	downsideDev := math.Sqrt(downside / float64(len(returns)))
+	s.Sharpe = excess / stdDev(returns) * math.Sqrt(perYear)
+	s.Sortino = excess / downsideDev * math.Sqrt(perYear)
+	if downsideDev == 0 {
+		s.Sortino = math.NaN()
+	}
+
+	best, worst := 0, 0
+	for i, r := range returns {
+		if r > returns[best] {
+			best = i
+		}
+		if r < returns[worst] {
+			worst = i
+		}
+	}
+	s.BestDay, s.BestDayDate = returns[best]*100, asset.Dates[best+1]
+	s.WorstDay, s.WorstDayDate = returns[worst]*100, asset.Dates[worst+1]
+
+	var peak, trough int
+	s.Dates = asset.Dates
+	s.Drawdown, peak, trough = drawdown(asset.Closes)
+	s.MaxDrawdown = s.Drawdown[trough]
+	s.MaxDrawdownPeak, s.MaxDrawdownTrough = asset.Dates[peak], asset.Dates[trough]
+
+	if len(benchmark.Dates) > 0 {
+		aligned := Align(asset, benchmark)
+		a, b := aligned[0].Returns(), aligned[1].Returns()
+		if len(a) >= minStatsDays {
+			s.Benchmark = benchmark.Symbol
+			s.Beta = covariance(a, b) / covariance(b, b)
+		}
+	}
+
+	return s, nil
+}
+
+// GetStats fetches daily closes of a symbol and calculates its statistics over the period.
+// Benchmark is best effort: beta is left out if the benchmark is empty or can not be fetched.
+func GetStats(yfc *yfapi.YFClient, symbol, period, benchmark string, riskFree float64) (*Stats, error) {
+	data, err := yfc.GetDailyChart(symbol, period)
+	if err != nil {
+		return nil, err
+	}
+
+	var bench Series
+	if benchmark != "" && benchmark != symbol {
+		if b, err := yfc.GetDailyChart(benchmark, period); err == nil {
+			bench = NewSeries(b)
+		}
+	}
+
+	return ComputeStats(NewSeries(data), bench, period, riskFree)
+}
+
+// StatsMessage formats statistics as a monospace table.
+func (s *Stats) StatsMessage() string {
+	date := func(t time.Time) string {
+		return t.Format("2006-01-02")
+	}
+	ratio := func(v float64) string {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "N/A"
+		}
+		return fmt.Sprintf("%.2f", v)
+	}
+
+	rows := [][2]string{
+		{"Total return", fmt.Sprintf("%+.2f%%", s.TotalReturn)},
+		{"Annual return", fmt.Sprintf("%+.2f%%", s.AnnualReturn)},
+		{"Volatility", fmt.Sprintf("%.2f%%", s.Volatility)},
+		{"Sharpe", ratio(s.Sharpe)},
+		{"Sortino", ratio(s.Sortino)},
+		{"Max drawdown", fmt.Sprintf("%.2f%%", s.MaxDrawdown)},
+	}
+	if s.MaxDrawdown < 0 {
+		rows = append(rows, [2]string{"", date(s.MaxDrawdownPeak) + " - " + date(s.MaxDrawdownTrough)})
+	}
+	if s.Benchmark != "" {
+		rows = append(rows, [2]string{"Beta vs " + s.Benchmark, ratio(s.Beta)})
+	}
+	rows = append(rows,
+		[2]string{"Best day", fmt.Sprintf("%+.2f%% %s", s.BestDay, date(s.BestDayDate))},
+		[2]string{"Worst day", fmt.Sprintf("%+.2f%% %s", s.WorstDay, date(s.WorstDayDate))},
+	)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*%s statistics (%s)*\n```\n", s.Symbol, s.Period))
+	for _, row := range rows {
+		sb.WriteString(fmt.Sprintf("%-14s %s\n", row[0], row[1]))
+	}
+	sb.WriteString("```\n")
+	sb.WriteString(fmt.Sprintf("_Daily returns from %s to %s_", date(s.From), date(s.To)))
+
+	return sb.String()
+}
+
+// StatsMessageInlineKeyboard has a button plotting the drawdown.
+func (s *Stats) StatsMessageInlineKeyboard() *tgbot.InlineKeyboardMarkup {
+	p := s.DrawdownParams()
+
+	return yfapi.NewCallbackKeyboard([][]tgbot.InlineKeyboardButton{{
+		tgbot.NewInlineKeyboardButtonData("Drawdown chart", p.CallbackData()),
+	}})
+}
+
+// DrawdownParams returns params of the drawdown chart of the statistics.
+func (s *Stats) DrawdownParams() *yfapi.ChartParams {
+	return &yfapi.ChartParams{
+		Symbol:      s.Symbol,
+		Interval:    s.Period,
+		Measurement: "drawdown",
+		Action:      yfapi.ActionChart,
+		Type:        "-",
+	}
+}
+
+func (s *Stats) ChartBytes(p *yfapi.ChartParams) (tgbot.FileBytes, error) {
+	return yfapi.DrawdownChartBytes(p, s.Dates, s.Drawdown)
+}
+
+// Intervals returns periods the drawdown can be plotted over.
+func (s *Stats) Intervals() []string {
+	return yfapi.StatsRanges
+}
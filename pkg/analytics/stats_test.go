@@ -0,0 +1,111 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"quote-telegram-bot/pkg/yfapi"
+)
+
+// walk returns closes following the daily returns, starting from 100.
+func walk(returns []float64) []float64 {
+	values := []float64{100}
+	for _, r := range returns {
+		values = append(values, values[len(values)-1]*(1+r))
+	}
+
+	return values
+}
+
+func zigzag(n int, scale float64) []float64 {
+	returns := make([]float64, n)
+	for i := range returns {
+		returns[i] = scale * 0.01 * float64(i%5-2)
+	}
+
+	return returns
+}
+
+func TestComputeStatsBeta(t *testing.T) {
+	dates := weekdays(time.Date(2023, 1, 2, 9, 30, 0, 0, newYork), 61)
+	market := Series{Symbol: "SPY", Dates: dates, Closes: walk(zigzag(60, 1))}
+
+	tests := []struct {
+		name  string
+		asset Series
+		want  float64
+	}{
+		{"itself", Series{Symbol: "SPY2", Dates: dates, Closes: market.Closes}, 1},
+		{"leveraged", Series{Symbol: "SSO", Dates: dates, Closes: walk(zigzag(60, 2))}, 2},
+		{"inverse", Series{Symbol: "SH", Dates: dates, Closes: walk(zigzag(60, -1))}, -1},
+	}
+
+	for _, tt := range tests {
+		s, err := ComputeStats(tt.asset, market, "3mo", 0)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if s.Benchmark != "SPY" || math.Abs(s.Beta-tt.want) > epsilon {
+			t.Errorf("%s: beta vs %q = %v, want %v", tt.name, s.Benchmark, s.Beta, tt.want)
+		}
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	dates := everyDay(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), 366)
+	returns := make([]float64, 365)
+	for i := range returns {
+		returns[i] = 0.001
+	}
+	// a single loss makes the deepest drawdown
+	returns[100] = -0.1
+
+	s, err := ComputeStats(Series{Symbol: "BTC-USD", Dates: dates, Closes: walk(returns)}, Series{}, "1y", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := (math.Pow(1.001, 364)*0.9 - 1) * 100
+	if math.Abs(s.TotalReturn-total) > 1e-6 {
+		t.Errorf("TotalReturn = %v, want %v", s.TotalReturn, total)
+	}
+	// a year of closes compounds to the annual return
+	if math.Abs(s.AnnualReturn-total) > 0.1 {
+		t.Errorf("AnnualReturn = %v, want about %v", s.AnnualReturn, total)
+	}
+	if math.Abs(s.MaxDrawdown+10) > 1e-6 || !s.MaxDrawdownPeak.Equal(dates[100]) || !s.MaxDrawdownTrough.Equal(dates[101]) {
+		t.Errorf("MaxDrawdown = %v from %s to %s, want -10 from %s to %s",
+			s.MaxDrawdown, s.MaxDrawdownPeak, s.MaxDrawdownTrough, dates[100], dates[101])
+	}
+	if math.Abs(s.WorstDay+10) > 1e-6 || !s.WorstDayDate.Equal(dates[101]) {
+		t.Errorf("WorstDay = %v on %s, want -10 on %s", s.WorstDay, s.WorstDayDate, dates[101])
+	}
+	// volatility is annualized by the number of bars per year, crypto trades every day of a 365.25-day year
+	volatility := stdDev(returns) * math.Sqrt(365.25) * 100
+	if math.Abs(s.Volatility-volatility) > 1e-6 {
+		t.Errorf("Volatility = %v, want %v", s.Volatility, volatility)
+	}
+	if s.Benchmark != "" || !math.IsNaN(s.Beta) {
+		t.Errorf("beta vs %q = %v, want none", s.Benchmark, s.Beta)
+	}
+}
+
+func TestComputeStatsWithoutLosses(t *testing.T) {
+	dates := weekdays(time.Date(2023, 1, 2, 9, 30, 0, 0, newYork), 30)
+	s, err := ComputeStats(Series{Symbol: "UP", Dates: dates, Closes: closes(30)}, Series{}, "3mo", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsNaN(s.Sortino) || s.MaxDrawdown != 0 {
+		t.Errorf("Sortino = %v, MaxDrawdown = %v, want NaN and 0", s.Sortino, s.MaxDrawdown)
+	}
+}
+
+func TestComputeStatsInsufficientData(t *testing.T) {
+	dates := weekdays(time.Date(2023, 1, 2, 9, 30, 0, 0, newYork), minStatsDays-1)
+	_, err := ComputeStats(Series{Symbol: "NEW", Dates: dates, Closes: closes(len(dates))}, Series{}, "1mo", 0)
+	if _, ok := err.(*yfapi.InsufficientDataError); !ok {
+		t.Errorf("error = %v, want InsufficientDataError", err)
+	}
+}
@@ -3,13 +3,26 @@ package helpers
 import "regexp"
 
 var (
-	forbiddenChars = regexp.MustCompile(`[^a-zA-Z0-9\-_\.=]`)
+	forbiddenChars = regexp.MustCompile(`[^a-zA-Z0-9\-_\.=\^]`)
 )
 
 func Sanitize(text string) string {
 	return forbiddenChars.ReplaceAllLiteralString(text, "")
 }
 
+// Truncate shortens text to at most n runes, marking the cut with an ellipsis.
+func Truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	if n <= 0 {
+		return ""
+	}
+
+	return string(runes[:n-1]) + "…"
+}
+
 func Retry(retry int, f func() error) error {
 	var (
 		errorCount int
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// UploadFiles makes a multipart request with several files, which BotAPI.UploadFile does not support,
+// e.g. to send a media group. Files are referred to from params by their field names as attach://<field>.
+func UploadFiles(bot *tgbot.BotAPI, endpoint string, params map[string]string, files map[string]tgbot.FileBytes) (tgbot.APIResponse, error) {
+	body := bytes.NewBuffer([]byte{})
+	w := multipart.NewWriter(body)
+	for key, value := range params {
+		if err := w.WriteField(key, value); err != nil {
+			return tgbot.APIResponse{}, err
+		}
+	}
+	for field, file := range files {
+		part, err := w.CreateFormFile(field, file.Name)
+		if err != nil {
+			return tgbot.APIResponse{}, err
+		}
+		if _, err = part.Write(file.Bytes); err != nil {
+			return tgbot.APIResponse{}, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return tgbot.APIResponse{}, err
+	}
+
+	res, err := bot.Client.Post(fmt.Sprintf(tgbot.APIEndpoint, bot.Token, endpoint), w.FormDataContentType(), body)
+	if err != nil {
+		return tgbot.APIResponse{}, err
+	}
+	defer res.Body.Close()
+
+	var resp tgbot.APIResponse
+	if err = json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return tgbot.APIResponse{}, err
+	}
+	if !resp.Ok {
+		return tgbot.APIResponse{}, errors.New(resp.Description)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,156 @@
+// Package ta implements technical analysis indicators over price series.
+// Values that can not be calculated yet due to insufficient lookback are NaN,
+// so results are always aligned index by index with the input.
+package ta
+
+import "math"
+
+// SMA returns simple moving average of values over period.
+func SMA(values []float64, period int) []float64 {
+	result := nanSlice(len(values))
+	if period <= 0 || len(values) < period {
+		return result
+	}
+
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			result[i] = sum / float64(period)
+		}
+	}
+
+	return result
+}
+
+// EMA returns exponential moving average of values over period. It is seeded with SMA of the first period values.
+func EMA(values []float64, period int) []float64 {
+	result := nanSlice(len(values))
+	if period <= 0 || len(values) < period {
+		return result
+	}
+
+	k := 2 / (float64(period) + 1)
+	var sum float64
+	for _, v := range values[:period] {
+		sum += v
+	}
+	result[period-1] = sum / float64(period)
+
+	for i := period; i < len(values); i++ {
+		result[i] = values[i]*k + result[i-1]*(1-k)
+	}
+
+	return result
+}
+
+// BollingerBands returns SMA of values over period and bands k population standard deviations above and below it.
+func BollingerBands(values []float64, period int, k float64) (middle, upper, lower []float64) {
+	middle = SMA(values, period)
+	upper = nanSlice(len(values))
+	lower = nanSlice(len(values))
+
+	for i := range values {
+		if math.IsNaN(middle[i]) {
+			continue
+		}
+
+		var variance float64
+		for _, v := range values[i-period+1 : i+1] {
+			variance += (v - middle[i]) * (v - middle[i])
+		}
+		sd := math.Sqrt(variance / float64(period))
+
+		upper[i] = middle[i] + k*sd
+		lower[i] = middle[i] - k*sd
+	}
+
+	return middle, upper, lower
+}
+
+// RSI returns relative strength index of values over period using Wilder's smoothing.
+func RSI(values []float64, period int) []float64 {
+	result := nanSlice(len(values))
+	if period <= 0 || len(values) <= period {
+		return result
+	}
+
+	var gain, loss float64
+	for i := 1; i <= period; i++ {
+		change := values[i] - values[i-1]
+		if change > 0 {
+			gain += change
+		} else {
+			loss -= change
+		}
+	}
+	gain /= float64(period)
+	loss /= float64(period)
+	result[period] = rsi(gain, loss)
+
+	for i := period + 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		currentGain, currentLoss := 0.0, 0.0
+		if change > 0 {
+			currentGain = change
+		} else {
+			currentLoss = -change
+		}
+		gain = (gain*float64(period-1) + currentGain) / float64(period)
+		loss = (loss*float64(period-1) + currentLoss) / float64(period)
+		result[i] = rsi(gain, loss)
+	}
+
+	return result
+}
+
+func rsi(gain, loss float64) float64 {
+	if loss == 0 {
+		return 100
+	}
+
+	return 100 - 100/(1+gain/loss)
+}
+
+// MACD returns difference of fast and slow EMAs of values, its EMA over signal period and their difference.
+func MACD(values []float64, fast, slow, signal int) (macd, signalLine, histogram []float64) {
+	macd = nanSlice(len(values))
+	signalLine = nanSlice(len(values))
+	histogram = nanSlice(len(values))
+
+	fastEMA, slowEMA := EMA(values, fast), EMA(values, slow)
+	for i := range values {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+
+	start := FirstValid(macd)
+	copy(signalLine[start:], EMA(macd[start:], signal))
+	for i := range values {
+		histogram[i] = macd[i] - signalLine[i]
+	}
+
+	return macd, signalLine, histogram
+}
+
+// FirstValid returns index of the first calculated value, or len(values) if there is none.
+func FirstValid(values []float64) int {
+	for i, v := range values {
+		if !math.IsNaN(v) {
+			return i
+		}
+	}
+
+	return len(values)
+}
+
+func nanSlice(n int) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = math.NaN()
+	}
+
+	return s
+}
@@ -0,0 +1,130 @@
+package ta
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func equal(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: len = %d, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if math.IsNaN(want[i]) {
+			if !math.IsNaN(got[i]) {
+				t.Errorf("%s[%d] = %v, want NaN", name, i, got[i])
+			}
+			continue
+		}
+		if math.Abs(got[i]-want[i]) > epsilon {
+			t.Errorf("%s[%d] = %v, want %v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestSMA(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		values []float64
+		period int
+		want   []float64
+	}{
+		{"period 3", []float64{1, 2, 3, 4, 5}, 3, []float64{nan, nan, 2, 3, 4}},
+		{"period 1", []float64{1, 2, 3}, 1, []float64{1, 2, 3}},
+		{"not enough values", []float64{1, 2}, 3, []float64{nan, nan}},
+		{"zero period", []float64{1, 2}, 0, []float64{nan, nan}},
+		{"empty", []float64{}, 3, []float64{}},
+	}
+
+	for _, tt := range tests {
+		equal(t, tt.name, SMA(tt.values, tt.period), tt.want)
+	}
+}
+
+func TestEMA(t *testing.T) {
+	nan := math.NaN()
+	// k = 2/(3+1) = 0.5, seeded with SMA(1, 2, 3) = 2
+	got := EMA([]float64{1, 2, 3, 4, 6}, 3)
+	equal(t, "EMA", got, []float64{nan, nan, 2, 3, 4.5})
+
+	equal(t, "not enough values", EMA([]float64{1, 2}, 3), []float64{nan, nan})
+}
+
+func TestBollingerBands(t *testing.T) {
+	nan := math.NaN()
+	middle, upper, lower := BollingerBands([]float64{2, 4, 4, 4, 5, 5, 7, 9}, 8, 2)
+
+	// population standard deviation of the series is exactly 2
+	equal(t, "middle", middle, []float64{nan, nan, nan, nan, nan, nan, nan, 5})
+	equal(t, "upper", upper, []float64{nan, nan, nan, nan, nan, nan, nan, 9})
+	equal(t, "lower", lower, []float64{nan, nan, nan, nan, nan, nan, nan, 1})
+}
+
+func TestFirstValid(t *testing.T) {
+	nan := math.NaN()
+	if got := FirstValid([]float64{nan, nan, 1}); got != 2 {
+		t.Errorf("FirstValid = %d, want 2", got)
+	}
+	if got := FirstValid([]float64{nan}); got != 1 {
+		t.Errorf("FirstValid = %d, want 1", got)
+	}
+}
+
+func TestRSI(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		values []float64
+		period int
+		want   []float64
+	}{
+		// changes are +2, -1, +2, -1, +2, -1: seeded with gain 1 and loss 0.5, then smoothed as (prev + current) / 2
+		{"mixed", []float64{10, 12, 11, 13, 12, 14, 13}, 2, []float64{
+			nan,
+			nan,
+			100 - 100/(1+1/0.5),
+			100 - 100/(1+1.5/0.25),
+			100 - 100/(1+0.75/0.625),
+			100 - 100/(1+1.375/0.3125),
+			100 - 100/(1+0.6875/0.65625),
+		}},
+		{"only gains", []float64{1, 2, 3, 4}, 2, []float64{nan, nan, 100, 100}},
+		{"not enough values", []float64{1, 2}, 2, []float64{nan, nan}},
+	}
+
+	for _, tt := range tests {
+		equal(t, tt.name, RSI(tt.values, tt.period), tt.want)
+	}
+}
+
+func TestMACD(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8}
+	macd, signal, histogram := MACD(values, 2, 3, 2)
+
+	fast, slow := EMA(values, 2), EMA(values, 3)
+	if FirstValid(macd) != 2 {
+		t.Fatalf("first valid MACD = %d, want 2", FirstValid(macd))
+	}
+	for i := 2; i < len(values); i++ {
+		if math.Abs(macd[i]-(fast[i]-slow[i])) > epsilon {
+			t.Errorf("macd[%d] = %v, want %v", i, macd[i], fast[i]-slow[i])
+		}
+	}
+
+	// signal is EMA(2) of MACD seeded with the average of its first two values
+	if FirstValid(signal) != 3 {
+		t.Fatalf("first valid signal = %d, want 3", FirstValid(signal))
+	}
+	if want := (macd[2] + macd[3]) / 2; math.Abs(signal[3]-want) > epsilon {
+		t.Errorf("signal[3] = %v, want %v", signal[3], want)
+	}
+	for i := 3; i < len(values); i++ {
+		if math.Abs(histogram[i]-(macd[i]-signal[i])) > epsilon {
+			t.Errorf("histogram[%d] = %v, want %v", i, histogram[i], macd[i]-signal[i])
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package yfapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	albumRanges = []string{"1d", "5d", "1mo", "6mo", "1y"}
+	// albumWorkers bounds concurrent Yahoo Finance requests and renders of a single album
+	albumWorkers = 3
+)
+
+// AlbumChart is a chart of a single range sent as a part of a media group.
+type AlbumChart struct {
+	Params *ChartParams
+	CachedChart
+}
+
+// GetChartAlbum renders price charts of several ranges concurrently. Charts are taken from the cache when possible,
+// otherwise fetched and rendered by a pool of albumWorkers. Ranges which fail are left out of the album.
+func (yfc *YFClient) GetChartAlbum(p *ChartParams, cache *ChartCache) ([]AlbumChart, error) {
+	album := make([]AlbumChart, len(albumRanges))
+	errs := make([]error, len(albumRanges))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < albumWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				album[i], errs[i] = yfc.albumChart(p, albumRanges[i], cache)
+			}
+		}()
+	}
+
+	for i := range albumRanges {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	charts := album[:0]
+	var err error
+	for i, c := range album {
+		if errs[i] != nil {
+			err = errs[i]
+			continue
+		}
+		charts = append(charts, c)
+	}
+
+	// Telegram media groups consist of at least two items
+	if len(charts) < 2 {
+		if err == nil {
+			err = fmt.Errorf("not enough charts for an album: %s", p.Symbol)
+		}
+		return nil, err
+	}
+
+	return charts, nil
+}
+
+func (yfc *YFClient) albumChart(p *ChartParams, period string, cache *ChartCache) (AlbumChart, error) {
+	params := *p
+	params.Interval = period
+	params.Action = ActionChart
+
+	if cached, ok := cache.Get(&params); ok {
+		return AlbumChart{Params: &params, CachedChart: cached}, nil
+	}
+
+	data, err := yfc.GetPriceChartWithLookback(params.Symbol, params.Interval, params.Lookback())
+	if err != nil {
+		return AlbumChart{}, err
+	}
+
+	chart, err := data.ChartBytes(&params)
+	if err != nil {
+		return AlbumChart{}, err
+	}
+
+	return AlbumChart{Params: &params, CachedChart: cache.Put(&params, chart, data.Intervals())}, nil
+}
+
+// NewMediaGroupParams returns params of sendMediaGroup and files to upload along with them.
+// Charts uploaded before are sent by file id.
+func NewMediaGroupParams(chatID int64, album []AlbumChart) (map[string]string, map[string]tgbot.FileBytes) {
+	media := make([]tgbot.InputMediaPhoto, 0, len(album))
+	files := make(map[string]tgbot.FileBytes, len(album))
+	for i, c := range album {
+		photo := tgbot.NewInputMediaPhoto(c.FileID)
+		if c.FileID == "" {
+			field := fmt.Sprintf("chart%d", i)
+			photo.Media = "attach://" + field
+			files[field] = c.File
+		}
+		if i == 0 {
+			photo.Caption = fmt.Sprintf("%s price", c.Params.Symbol)
+		}
+		media = append(media, photo)
+	}
+
+	mediaJSON, _ := json.Marshal(media)
+
+	params := map[string]string{
+		"chat_id": strconv.FormatInt(chatID, 10),
+		"media":   string(mediaJSON),
+	}
+
+	return params, files
+}
@@ -0,0 +1,107 @@
+package yfapi
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ChartCache keeps rendered charts until their data gets stale. Once a chart is uploaded to Telegram,
+// its file id is kept as well, so an identical chart is sent by reference instead of being uploaded again.
+type ChartCache struct {
+	mu      sync.Mutex
+	entries map[string]*CachedChart
+}
+
+type CachedChart struct {
+	File tgbot.FileBytes
+	// FileID is empty until the chart is uploaded
+	FileID    string
+	Intervals []string
+	expires   time.Time
+}
+
+func NewChartCache() *ChartCache {
+	return &ChartCache{
+		entries: make(map[string]*CachedChart),
+	}
+}
+
+// Get returns a copy of the cached chart, so it can be used while the cache is updated.
+func (cc *ChartCache) Get(p *ChartParams) (CachedChart, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	entry, ok := cc.entries[p.cacheKey()]
+	if !ok || time.Now().After(entry.expires) {
+		return CachedChart{}, false
+	}
+
+	return *entry, true
+}
+
+// Put caches a rendered chart for as long as its data is considered fresh. Expired charts are evicted meanwhile.
+func (cc *ChartCache) Put(p *ChartParams, file tgbot.FileBytes, intervals []string) CachedChart {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range cc.entries {
+		if now.After(entry.expires) {
+			delete(cc.entries, key)
+		}
+	}
+
+	entry := &CachedChart{
+		File:      file,
+		Intervals: intervals,
+		expires:   now.Add(p.ttl()),
+	}
+	cc.entries[p.cacheKey()] = entry
+
+	return *entry
+}
+
+// SetFileID remembers the largest photo size of a message the chart was sent with.
+func (cc *ChartCache) SetFileID(p *ChartParams, m tgbot.Message) {
+	if len(m.Photo) == 0 {
+		return
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if entry, ok := cc.entries[p.cacheKey()]; ok {
+		entry.FileID = m.Photo[len(m.Photo)-1].FileID
+	}
+}
+
+// cacheKey identifies a chart regardless of the button action it was requested with.
+func (p *ChartParams) cacheKey() string {
+	key := *p
+	key.Action = ActionChart
+
+	return fmt.Sprintf("%s|%s|%g|%s", key.CallbackData(), p.Output.Theme, p.Output.scale(), p.Output.Format)
+}
+
+// ttl returns how long chart data stays fresh. Intraday prices change every minute, daily bars change slowly,
+// while fundamentals and fund breakdowns are updated with reports.
+func (p *ChartParams) ttl() time.Duration {
+	switch p.Measurement {
+	case "price", "compare", "drawdown":
+		if _, to, ok := ParseDateRange(p.Interval); ok && to.AddDate(0, 0, 1).Before(time.Now().Add(-24*time.Hour)) {
+			return 24 * time.Hour
+		}
+
+		_, interval := periodInterval(p.Interval)
+		if granularities[interval] < 24*time.Hour {
+			return time.Minute
+		}
+
+		return 15 * time.Minute
+	default:
+		return 6 * time.Hour
+	}
+}
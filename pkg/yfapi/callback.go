@@ -0,0 +1,270 @@
+package yfapi
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"time"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// CallbackAction is what a button does when pressed.
+type CallbackAction byte
+
+const (
+	// ActionQuote sends a quote of a symbol found by search
+	ActionQuote CallbackAction = iota + 1
+	// ActionChart sends a new message with a chart
+	ActionChart
+	// ActionChartUpdate replaces the chart of the message the button belongs to
+	ActionChartUpdate
+	// ActionChartAlbum sends price charts of several ranges as a media group
+	ActionChartAlbum
+)
+
+// Callback data is versioned, so buttons of messages sent by older bot versions are recognized.
+// Version 1 is a base64 encoded binary payload:
+//
+//	quote: action, symbol
+//	chart: action, measurement, type, style, options, interval[, from, to], symbol
+//
+// Strings known in advance are encoded as 1-based indices, options as a bit mask, custom date ranges as days since epoch.
+// Symbol is the only free-form field, it takes the rest of the payload.
+// Telegram limits callback data to 64 bytes, which leaves up to 40 bytes for the symbol of a chart, 36 with a custom
+// date range. Buttons which do not fit, e.g. comparisons of several long symbols, are left out of keyboards.
+const (
+	callbackPrefix  = "!"
+	callbackVersion = "1"
+
+	dateRangeCode = 0xff
+
+	maxCallbackDataLength = 64
+)
+
+var (
+	callbackMeasurements = []string{"price", "earnings", "revenue", "holdings", "sectors", "compare", "drawdown"}
+	callbackTypes        = []string{"hasEarnings", "hasHoldings", "hasNoEarnings", "-"}
+	callbackStyles       = []string{"line", "candles"}
+	callbackIntervals    = []string{"1d", "5d", "1mo", "3mo", "6mo", "ytd", "1y", "2y", "5y", "10y", "max", "quarterly", "yearly", "-", "24h", "7d"}
+	callbackOptions      = []string{volumeOption, smaOption, emaOption, bollingerOption, rsiOption, macdOption}
+)
+
+type Callback struct {
+	Action CallbackAction
+	// Symbol is set for ActionQuote
+	Symbol string
+	// Chart is set for chart actions
+	Chart *ChartParams
+}
+
+// StaleCallbackError is returned for buttons which can not be handled anymore, e.g. sent by an older bot version.
+type StaleCallbackError struct {
+	Data string
+}
+
+func (e *StaleCallbackError) Error() string {
+	return "This button is outdated, please request the quote again"
+}
+
+// QuoteCallbackData returns callback data of a button showing a quote of the symbol.
+func QuoteCallbackData(symbol string) string {
+	return encodeCallback(append([]byte{byte(ActionQuote)}, symbol...))
+}
+
+func (p *ChartParams) CallbackData() string {
+	payload := []byte{
+		byte(p.Action),
+		callbackIndex(callbackMeasurements, p.Measurement),
+		callbackIndex(callbackTypes, p.Type),
+		callbackIndex(callbackStyles, p.Style),
+		0,
+	}
+
+	for i, option := range callbackOptions {
+		if p.HasOption(option) {
+			payload[4] |= 1 << i
+		}
+	}
+
+	if from, to, ok := ParseDateRange(p.Interval); ok {
+		payload = append(payload, dateRangeCode, 0, 0, 0, 0)
+		binary.BigEndian.PutUint16(payload[len(payload)-4:], epochDays(from))
+		binary.BigEndian.PutUint16(payload[len(payload)-2:], epochDays(to))
+	} else {
+		payload = append(payload, callbackIndex(callbackIntervals, p.Interval))
+	}
+
+	return encodeCallback(append(payload, p.Symbol...))
+}
+
+// DecodeCallback parses callback data of any button sent by the bot, including ones of older bot versions.
+func DecodeCallback(data string) (*Callback, error) {
+	if !strings.HasPrefix(data, callbackPrefix) {
+		return decodeLegacyCallback(data)
+	}
+
+	stale := &StaleCallbackError{Data: data}
+	data = strings.TrimPrefix(data, callbackPrefix)
+	if !strings.HasPrefix(data, callbackVersion) {
+		return nil, stale
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(data, callbackVersion))
+	if err != nil || len(payload) == 0 {
+		return nil, stale
+	}
+
+	action := CallbackAction(payload[0])
+	switch action {
+	case ActionQuote:
+		if len(payload) == 1 {
+			return nil, stale
+		}
+		return &Callback{Action: action, Symbol: string(payload[1:])}, nil
+	case ActionChart, ActionChartUpdate, ActionChartAlbum:
+		p, ok := decodeChartParams(payload)
+		if !ok {
+			return nil, stale
+		}
+		return &Callback{Action: action, Chart: p}, nil
+	default:
+		return nil, stale
+	}
+}
+
+func decodeChartParams(payload []byte) (*ChartParams, bool) {
+	const header = 6
+	if len(payload) <= header {
+		return nil, false
+	}
+
+	p := &ChartParams{Action: CallbackAction(payload[0])}
+	var ok bool
+	if p.Measurement, ok = callbackValue(callbackMeasurements, payload[1]); !ok {
+		return nil, false
+	}
+	if p.Type, ok = callbackValue(callbackTypes, payload[2]); !ok {
+		return nil, false
+	}
+	// charts other than price ones, e.g. comparisons, have no style
+	if payload[3] != 0 {
+		if p.Style, ok = callbackValue(callbackStyles, payload[3]); !ok {
+			return nil, false
+		}
+	}
+
+	for i, option := range callbackOptions {
+		if payload[4]&(1<<i) != 0 {
+			p.Options += option
+		}
+	}
+
+	symbol := payload[header:]
+	if payload[5] == dateRangeCode {
+		if len(symbol) <= 4 {
+			return nil, false
+		}
+		p.Interval = FormatDateRange(epochDate(symbol[0:2]), epochDate(symbol[2:4]))
+		symbol = symbol[4:]
+	} else if p.Interval, ok = callbackValue(callbackIntervals, payload[5]); !ok {
+		return nil, false
+	}
+	p.Symbol = string(symbol)
+
+	return p, true
+}
+
+// decodeLegacyCallback parses unversioned callback data: a raw symbol of a search result,
+// or pipe-separated chart params Symbol|Interval|Measurement|Cmd|Type[|Style[|Options]].
+func decodeLegacyCallback(data string) (*Callback, error) {
+	fields := strings.Split(data, "|")
+	if len(fields) == 1 {
+		return &Callback{Action: ActionQuote, Symbol: data}, nil
+	}
+
+	minLen, maxLen := 5, 7
+	if len(fields) < minLen || len(fields) > maxLen {
+		return nil, &StaleCallbackError{Data: data}
+	}
+
+	// buttons sent before chart styles and options were introduced have no such fields
+	style, options := defaultChartStyle, ""
+	if len(fields) > 5 {
+		style = fields[5]
+	}
+	if len(fields) > 6 {
+		options = fields[6]
+	}
+
+	action := ActionChartUpdate
+	if fields[3] == "initial" {
+		action = ActionChart
+	}
+
+	return &Callback{
+		Action: action,
+		Chart: &ChartParams{
+			Symbol:      fields[0],
+			Interval:    fields[1],
+			Measurement: fields[2],
+			Action:      action,
+			Type:        fields[4],
+			Style:       style,
+			Options:     options,
+		},
+	}, nil
+}
+
+// NewCallbackKeyboard leaves out buttons with callback data too long for Telegram, which rejects the whole message
+// otherwise, along with rows left empty.
+func NewCallbackKeyboard(rows [][]tgbot.InlineKeyboardButton) *tgbot.InlineKeyboardMarkup {
+	kb := make([][]tgbot.InlineKeyboardButton, 0, len(rows))
+	for _, row := range rows {
+		fitting := make([]tgbot.InlineKeyboardButton, 0, len(row))
+		for _, b := range row {
+			if b.CallbackData == nil || len(*b.CallbackData) <= maxCallbackDataLength {
+				fitting = append(fitting, b)
+			}
+		}
+		if len(fitting) > 0 {
+			kb = append(kb, fitting)
+		}
+	}
+
+	return &tgbot.InlineKeyboardMarkup{
+		InlineKeyboard: kb,
+	}
+}
+
+func encodeCallback(payload []byte) string {
+	return callbackPrefix + callbackVersion + base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// callbackIndex returns 1-based index of the value, zero is reserved for values unknown to this bot version.
+func callbackIndex(values []string, value string) byte {
+	for i, v := range values {
+		if v == value {
+			return byte(i + 1)
+		}
+	}
+
+	return 0
+}
+
+func callbackValue(values []string, index byte) (string, bool) {
+	if index == 0 || int(index) > len(values) {
+		return "", false
+	}
+
+	return values[index-1], true
+}
+
+// epochDays returns a number of days since the epoch, NewDateRange keeps dates within 16 bits.
+func epochDays(t time.Time) uint16 {
+	return uint16(t.Unix() / 86400)
+}
+
+func epochDate(b []byte) time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint16(b))*86400, 0).UTC()
+}
@@ -0,0 +1,123 @@
+package yfapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChartCallbackRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		params ChartParams
+	}{
+		{"price", ChartParams{Symbol: "AAPL", Interval: "1y", Measurement: "price", Action: ActionChart, Type: "hasEarnings", Style: "candles", Options: rsiOption + macdOption}},
+		{"update", ChartParams{Symbol: "BRK-B", Interval: "5d", Measurement: "price", Action: ActionChartUpdate, Type: "hasNoEarnings", Style: "line"}},
+		{"album", ChartParams{Symbol: "^GSPC", Interval: "1d", Measurement: "price", Action: ActionChartAlbum, Type: "hasNoEarnings", Style: "line", Options: volumeOption}},
+		{"date range", ChartParams{Symbol: "EURUSD=X", Interval: "20200101-20201231", Measurement: "price", Action: ActionChart, Type: "hasNoEarnings", Style: "line"}},
+		{"same day range", ChartParams{Symbol: "AAPL", Interval: "20200505-20200505", Measurement: "price", Action: ActionChart, Type: "hasNoEarnings", Style: "line"}},
+		{"no style", ChartParams{Symbol: "AAPL,MSFT,QQQ", Interval: "1mo", Measurement: "compare", Action: ActionChartUpdate, Type: "-"}},
+		{"holdings", ChartParams{Symbol: "VOO", Interval: "-", Measurement: "holdings", Action: ActionChartUpdate, Type: "hasHoldings"}},
+		{"drawdown", ChartParams{Symbol: "SPY", Interval: "5y", Measurement: "drawdown", Action: ActionChart, Type: "-"}},
+		{"rolling range", ChartParams{Symbol: "BTC-USD", Interval: "24h", Measurement: "price", Action: ActionChartUpdate, Type: "hasNoEarnings", Style: "line"}},
+		{"earnings", ChartParams{Symbol: "MSFT", Interval: "quarterly", Measurement: "earnings", Action: ActionChartUpdate, Type: "hasEarnings", Style: "line"}},
+	}
+
+	for _, tt := range tests {
+		data := tt.params.CallbackData()
+		if len(data) > maxCallbackDataLength {
+			t.Errorf("%s: callback data is %d bytes long", tt.name, len(data))
+		}
+
+		cb, err := DecodeCallback(data)
+		if err != nil {
+			t.Errorf("%s: DecodeCallback(%q) error = %v", tt.name, data, err)
+			continue
+		}
+		if cb.Action != tt.params.Action || cb.Chart == nil || *cb.Chart != tt.params {
+			t.Errorf("%s: DecodeCallback(%q) = %+v, want %+v", tt.name, data, cb.Chart, tt.params)
+		}
+	}
+}
+
+func TestQuoteCallbackRoundTrip(t *testing.T) {
+	cb, err := DecodeCallback(QuoteCallbackData("SBER.ME"))
+	if err != nil || cb.Action != ActionQuote || cb.Symbol != "SBER.ME" {
+		t.Errorf("DecodeCallback() = %+v, %v, want quote of SBER.ME", cb, err)
+	}
+}
+
+func TestDecodeLegacyCallback(t *testing.T) {
+	tests := []struct {
+		data string
+		want Callback
+	}{
+		{"TSLA", Callback{Action: ActionQuote, Symbol: "TSLA"}},
+		{"AAPL|1mo|price|initial|hasEarnings", Callback{Action: ActionChart, Chart: &ChartParams{
+			Symbol: "AAPL", Interval: "1mo", Measurement: "price", Action: ActionChart, Type: "hasEarnings", Style: defaultChartStyle,
+		}}},
+		{"AAPL|1y|price|update|hasEarnings|candles", Callback{Action: ActionChartUpdate, Chart: &ChartParams{
+			Symbol: "AAPL", Interval: "1y", Measurement: "price", Action: ActionChartUpdate, Type: "hasEarnings", Style: "candles",
+		}}},
+		{"AAPL|1y|price|update|hasEarnings|line|vs", Callback{Action: ActionChartUpdate, Chart: &ChartParams{
+			Symbol: "AAPL", Interval: "1y", Measurement: "price", Action: ActionChartUpdate, Type: "hasEarnings", Style: "line", Options: "vs",
+		}}},
+	}
+
+	for _, tt := range tests {
+		cb, err := DecodeCallback(tt.data)
+		if err != nil {
+			t.Errorf("DecodeCallback(%q) error = %v", tt.data, err)
+			continue
+		}
+		if cb.Action != tt.want.Action || cb.Symbol != tt.want.Symbol {
+			t.Errorf("DecodeCallback(%q) = %+v, want %+v", tt.data, cb, tt.want)
+		}
+		if (cb.Chart == nil) != (tt.want.Chart == nil) || cb.Chart != nil && *cb.Chart != *tt.want.Chart {
+			t.Errorf("DecodeCallback(%q) chart = %+v, want %+v", tt.data, cb.Chart, tt.want.Chart)
+		}
+	}
+}
+
+func TestStaleCallback(t *testing.T) {
+	for _, data := range []string{"!2AQID", "!1", "!1###", "!1CQ", "AAPL|1y|price", "AAPL|1y|price|update|hasEarnings|line|vs|extra"} {
+		if _, err := DecodeCallback(data); err == nil {
+			t.Errorf("DecodeCallback(%q) error = nil, want stale", data)
+		} else if _, ok := err.(*StaleCallbackError); !ok {
+			t.Errorf("DecodeCallback(%q) error = %v, want stale", data, err)
+		}
+	}
+}
+
+func TestChartKeyboardCallbackLength(t *testing.T) {
+	symbols := []string{"ABCDEFGHIJ.ME", "KLMNOPQRST.ME", "UVWXYZABCD.ME", "EFGHIJKLMN.ME"}
+	tests := []struct {
+		name    string
+		params  ChartParams
+		buttons bool
+	}{
+		{"short symbols", ChartParams{Symbol: "AAPL,MSFT", Interval: "1mo", Measurement: "compare", Type: "-"}, true},
+		{"long symbols", ChartParams{Symbol: strings.Join(symbols, ","), Interval: "1mo", Measurement: "compare", Type: "-"}, false},
+		// buttons switching to ranges fit, while ones keeping the date range do not
+		{"date range", ChartParams{Symbol: strings.Repeat("A", 38), Interval: "20200101-20201231", Measurement: "price", Type: "hasNoEarnings", Style: "line"}, true},
+		{"long symbol", ChartParams{Symbol: strings.Repeat("A", 41), Interval: "1y", Measurement: "price", Type: "hasNoEarnings", Style: "line"}, false},
+	}
+
+	for _, tt := range tests {
+		kb := ChartKeyboard(&tt.params, []string{"1mo", "1y", "5y"})
+		n := 0
+		for _, row := range kb.InlineKeyboard {
+			if len(row) == 0 {
+				t.Errorf("%s: keyboard has an empty row", tt.name)
+			}
+			for _, b := range row {
+				n++
+				if b.CallbackData != nil && len(*b.CallbackData) > maxCallbackDataLength {
+					t.Errorf("%s: button %q has %d bytes of callback data", tt.name, b.Text, len(*b.CallbackData))
+				}
+			}
+		}
+		if (n > 0) != tt.buttons {
+			t.Errorf("%s: keyboard has %d buttons", tt.name, n)
+		}
+	}
+}
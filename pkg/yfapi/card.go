@@ -0,0 +1,222 @@
+package yfapi
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+const (
+	// SparklinePeriod is a range of the price line drawn on quote cards
+	SparklinePeriod = "1mo"
+
+	cardWidth   = 640
+	cardHeight  = 360
+	cardPadding = 24
+)
+
+// CardBytes renders a quote card: name, price and change, a price sparkline and key ratios of the quote type.
+// Sparkline is optional, the card is laid out without it when spark is nil or has no data.
+func (q *Quote) CardBytes(spark *Chart, o ChartOutput) (tgbot.FileBytes, error) {
+	theme := o.theme()
+	width, height, padding := o.size(cardWidth), o.size(cardHeight), o.size(cardPadding)
+
+	r, err := o.renderer()(width, height)
+	if err != nil {
+		return tgbot.FileBytes{}, err
+	}
+	r.SetDPI(o.dpi())
+
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return tgbot.FileBytes{}, err
+	}
+	r.SetFont(font)
+
+	fillRect(r, 0, 0, width, height, theme.Background)
+
+	text := func(s string, size float64, color drawing.Color, x, y int) chart.Box {
+		r.SetFontSize(size)
+		r.SetFontColor(color)
+		box := r.MeasureText(s)
+		r.Text(s, x, y+box.Height())
+		return box
+	}
+
+	y := padding
+	box := text(q.Name(), 20, theme.Text, padding, y)
+	y += box.Height() + o.size(8)
+
+	subtitle := []string{q.Symbol()}
+	if q.Price.Exchange != "" {
+		subtitle = append(subtitle, q.Price.Exchange)
+	}
+	subtitle = append(subtitle, q.Type())
+	box = text(strings.Join(subtitle, " · "), 11, theme.Muted, padding, y)
+	y += box.Height() + o.size(16)
+
+	box = text(q.MarketPrice(), 28, theme.Text, padding, y)
+	changeColor := theme.Up
+	if q.Price.Change.Raw < 0 {
+		changeColor = theme.Down
+	}
+	// change shares the baseline with the price
+	r.SetFontSize(14)
+	changeHeight := r.MeasureText(q.Change()).Height()
+	text(q.Change(), 14, changeColor, padding+box.Width()+o.size(12), y+box.Height()-changeHeight)
+	y += box.Height() + o.size(16)
+
+	sparkBox := chart.Box{Top: y, Left: padding, Right: width - padding, Bottom: y + o.size(80)}
+	if spark != nil && drawSparkline(r, sparkBox, spark, theme, o) {
+		text(strings.ToUpper(SparklinePeriod), 9, theme.Muted, sparkBox.Right-o.size(24), sparkBox.Top)
+		y = sparkBox.Bottom + o.size(16)
+	}
+
+	// key ratios are laid out in two columns of label and value pairs
+	ratios := q.cardRatios()
+	columnWidth := (width - 2*padding) / 2
+	rowHeight := o.size(26)
+	for i, ratio := range ratios {
+		x := padding + (i%2)*columnWidth
+		row := y + (i/2)*rowHeight
+		if row+rowHeight > height-padding/2 {
+			break
+		}
+		text(ratio[0], 11, theme.Muted, x, row)
+		r.SetFontSize(12)
+		value := r.MeasureText(ratio[1])
+		text(ratio[1], 12, theme.Text, x+columnWidth-value.Width()-o.size(16), row)
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err = r.Save(buffer); err != nil {
+		return tgbot.FileBytes{}, err
+	}
+
+	return o.fileBytes(buffer.Bytes()), nil
+}
+
+// drawSparkline draws closes of the chart within the box, coloured by the direction of the price over the period.
+func drawSparkline(r chart.Renderer, box chart.Box, c *Chart, theme ChartTheme, o ChartOutput) bool {
+	if len(c.Indicators.Quote) == 0 || c.validate(c.Meta.Symbol, SparklinePeriod) != nil {
+		return false
+	}
+
+	closes := c.completeBars().Indicators.Quote[0].Close
+	if len(closes) < 2 {
+		return false
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range closes {
+		low, high = math.Min(low, v), math.Max(high, v)
+	}
+	if high == low {
+		high = low + 1
+	}
+
+	point := func(i int) (int, int) {
+		x := box.Left + i*box.Width()/(len(closes)-1)
+		y := box.Bottom - int(float64(box.Height())*(closes[i]-low)/(high-low))
+		return x, y
+	}
+
+	color := theme.Up
+	if closes[len(closes)-1] < closes[0] {
+		color = theme.Down
+	}
+
+	r.SetFillColor(color.WithAlpha(40))
+	r.MoveTo(box.Left, box.Bottom)
+	for i := range closes {
+		r.LineTo(point(i))
+	}
+	r.LineTo(box.Right, box.Bottom)
+	r.Close()
+	r.Fill()
+
+	r.SetStrokeColor(color)
+	r.SetStrokeWidth(o.stroke(2))
+	r.MoveTo(point(0))
+	for i := range closes[1:] {
+		r.LineTo(point(i + 1))
+	}
+	r.Stroke()
+
+	return true
+}
+
+// cardRatios returns label and value pairs shown on a quote card, values Yahoo Finance has no data for are left out.
+func (q *Quote) cardRatios() [][2]string {
+	var ratios [][2]string
+	switch q.Type() {
+	case "EQUITY":
+		ratios = [][2]string{
+			{"Market Cap", q.MarketCap()},
+			{"P/E", q.PToE()},
+			{"EPS", q.EPS()},
+			{"P/B", q.PriceToBook()},
+			{"P/S", q.PriceToSales()},
+			{"Beta", q.Beta()},
+			{"ROE", q.ROE()},
+			{"Debt/Equity", q.DebtToEquity()},
+			{"52W Range", q.FiftyTwoWeekRange()},
+		}
+	case "ETF":
+		ratios = [][2]string{
+			{"Assets", q.Assets()},
+			{"Expense Ratio", q.ExpenseRatio()},
+			{"Beta(3Y)", q.Beta()},
+			{"Return(YTD)", q.Return("YTD")},
+			{"Return(Avg, 3Y)", q.Return("3Y")},
+			{"Return(Avg, 5Y)", q.Return("5Y")},
+			{"52W Range", q.FiftyTwoWeekRange()},
+		}
+	case "MUTUALFUND":
+		ratios = [][2]string{
+			{"NAV", q.NAV()},
+			{"Assets", q.Assets()},
+			{"Expense Ratio", q.ExpenseRatio()},
+			{"Yield", q.Yield()},
+			{"Return(YTD)", q.Return("YTD")},
+			{"Return(Avg, 5Y)", q.Return("5Y")},
+		}
+	case "CRYPTOCURRENCY":
+		ratios = [][2]string{
+			{"Market Cap", q.MarketCap()},
+			{"Volume(24h)", q.Volume24Hr()},
+			{"Day Range", q.DayRange()},
+			{"52W Range", q.FiftyTwoWeekRange()},
+			{"Circulating", q.CirculatingSupply()},
+		}
+	default:
+		ratios = [][2]string{
+			{"Open", q.Open()},
+			{"Prev Close", q.PreviousClose()},
+			{"Day Range", q.DayRange()},
+			{"52W Range", q.FiftyTwoWeekRange()},
+		}
+		if q.Type() == "FUTURE" {
+			ratios = append(ratios, [2]string{"Open Interest", q.OpenInterest()}, [2]string{"Expires", q.ExpireDate()})
+		}
+	}
+
+	known := ratios[:0]
+	for _, ratio := range ratios {
+		if ratio[1] != "N/A" && ratio[1] != "" {
+			known = append(known, ratio)
+		}
+	}
+
+	return known
+}
+
+// CardCaption is a short text sent along with a quote card, so the quote can be found by searching the chat.
+func (q *Quote) CardCaption() string {
+	return fmt.Sprintf("%s %s %s", q.Symbol(), q.MarketPrice(), q.Change())
+}
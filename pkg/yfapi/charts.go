@@ -4,18 +4,23 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
 
 	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+	"quote-telegram-bot/pkg/ta"
 )
 
 type Chart struct {
 	Meta       ChartMeta       `mapstructure:"meta"`
 	Indicators ChartIndicators `mapstructure:"indicators"`
 	Timestamps []int           `mapstructure:"timestamp"`
+	// Lookback is a number of leading bars fetched only to calculate indicators, they are not plotted
+	Lookback int
 }
 
 type ChartResponse struct {
@@ -34,6 +39,10 @@ type ChartMeta struct {
 	Symbol          string   `mapstructure:"symbol"`
 	DataGranularity string   `mapstructure:"dataGranularity"`
 	Range           string   `mapstructure:"range"`
+	InstrumentType  string   `mapstructure:"instrumentType"`
+	PreviousClose   float64  `mapstructure:"chartPreviousClose"`
+	Timezone        string   `mapstructure:"exchangeTimezoneName"`
+	GMTOffset       int      `mapstructure:"gmtoffset"`
 	ValidIntervals  []string `mapstructure:"validRanges"`
 }
 
@@ -53,8 +62,18 @@ type ChartParams struct {
 	Symbol      string
 	Interval    string
 	Measurement string
-	Cmd         string
+	Action      CallbackAction
 	Type        string
+	Style       string
+	// Options is a set of single-letter chart options, e.g. volumeOption
+	Options string
+	// Output is not part of callback data, it is restored from settings of the user who pressed a button
+	Output ChartOutput
+}
+
+// Comparison is a set of price charts of several symbols over the same period.
+type Comparison struct {
+	Charts []*Chart
 }
 
 type Chartable interface {
@@ -62,27 +81,117 @@ type Chartable interface {
 	Intervals() []string
 }
 
-func NewChartParams(callbackData string) (*ChartParams, error) {
-	data := strings.Split(callbackData, "|")
-	minLen := 5
-	if len(data) != minLen {
-		return nil, fmt.Errorf("provided data has invalid size(%d != %d): %s", len(data), minLen, callbackData)
+// Lookback returns number of bars needed before the first visible one for enabled indicators to be valid from it.
+func (p *ChartParams) Lookback() int {
+	lookback := 0
+	for option, bars := range map[string]int{
+		smaOption:       smaPeriod - 1,
+		emaOption:       emaPeriod - 1,
+		bollingerOption: bollingerPeriod - 1,
+		rsiOption:       rsiPeriod,
+		macdOption:      macdSlow + macdSignal - 2,
+	} {
+		if p.HasOption(option) && bars > lookback {
+			lookback = bars
+		}
 	}
 
-	return &ChartParams{
-		Symbol:      data[0],
-		Interval:    data[1],
-		Measurement: data[2],
-		Cmd:         data[3],
-		Type:        data[4],
-	}, nil
+	return lookback
 }
 
-func NewMediaUpdateParams(message *tgbot.Message, p *ChartParams, i []string) map[string]string {
+func (p *ChartParams) HasOption(option string) bool {
+	return strings.Contains(p.Options, option)
+}
+
+// toggleData returns callback data to redraw the chart with the option switched on or off.
+func (p *ChartParams) toggleData(option string) string {
+	update := *p
+	update.Action = ActionChartUpdate
+	if p.HasOption(option) {
+		update.Options = strings.Replace(p.Options, option, "", 1)
+	} else {
+		update.Options = p.Options + option
+	}
+
+	return update.CallbackData()
+}
+
+// FormatDateRange encodes a custom chart period as ChartParams.Interval. Dates are kept compact to fit callback data.
+func FormatDateRange(from, to time.Time) string {
+	return from.Format(dateRangeLayout) + "-" + to.Format(dateRangeLayout)
+}
+
+// NewDateRange validates dates of a custom chart period and encodes it with FormatDateRange.
+// TO date is included, so both dates may be the same day.
+func NewDateRange(from, to time.Time) (string, error) {
+	if to.Before(from) {
+		return "", &QueryError{Code: "Bad Request", Description: "FROM date must not be after TO date"}
+	}
+	// dates are sent in callback data as 16-bit numbers of days since the epoch
+	first, last := time.Unix(0, 0).UTC(), epochDate([]byte{0xff, 0xff})
+	if from.Before(first) || to.After(last) {
+		return "", &QueryError{
+			Code:        "Bad Request",
+			Description: fmt.Sprintf("Dates must be from %s to %s", first.Format("2006-01-02"), last.Format("2006-01-02")),
+		}
+	}
+
+	return FormatDateRange(from, to), nil
+}
+
+// ParseDateRange decodes a custom chart period made by FormatDateRange.
+func ParseDateRange(period string) (time.Time, time.Time, bool) {
+	dates := strings.Split(period, "-")
+	if len(dates) != 2 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	from, err := time.Parse(dateRangeLayout, dates[0])
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	to, err := time.Parse(dateRangeLayout, dates[1])
+	if err != nil || to.Before(from) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
+
+// dateRangeQuery returns chart query of a custom period. Yahoo Finance excludes bars starting at period2,
+// so it is set to the day after TO date.
+func dateRangeQuery(from, to time.Time, interval string) string {
+	return fmt.Sprintf("period1=%d&period2=%d&interval=%s", from.Unix(), to.AddDate(0, 0, 1).Unix(), interval)
+}
+
+func periodTitle(period string) string {
+	if from, to, ok := ParseDateRange(period); ok {
+		return from.Format("2006-01-02") + " - " + to.Format("2006-01-02")
+	}
+
+	return period
+}
+
+// updateData returns callback data to redraw the chart with another interval or measurement.
+func (p *ChartParams) updateData(interval, measurement string) string {
+	update := *p
+	update.Interval = interval
+	update.Measurement = measurement
+	update.Action = ActionChartUpdate
+
+	return update.CallbackData()
+}
+
+// NewMediaUpdateParams replaces the chart of a message. Empty fileID means the chart is uploaded as charts.png.
+func NewMediaUpdateParams(message *tgbot.Message, p *ChartParams, i []string, fileID string) map[string]string {
 	media := struct {
 		Type  string `json:"type"`
 		Media string `json:"media"`
 	}{Type: "photo", Media: "attach://charts.png"}
+	if fileID != "" {
+		media.Media = fileID
+	}
 
 	mediaJSON, _ := json.Marshal(media)
 	kbJSON, _ := json.Marshal(ChartKeyboard(p, i))
@@ -97,27 +206,72 @@ func NewMediaUpdateParams(message *tgbot.Message, p *ChartParams, i []string) ma
 	return updateParams
 }
 
+// Intervals returns periods the chart can be switched to, crypto gets rolling ranges instead of the shortest ones.
 func (c *Chart) Intervals() []string {
 	intervals := make([]string, 0, len(c.Meta.ValidIntervals))
 	for _, interval := range c.Meta.ValidIntervals {
-		if _, ok := priceIntervals[interval]; ok {
-			intervals = append(intervals, interval)
+		if _, ok := priceIntervals[interval]; !ok {
+			continue
+		}
+		if rolling, ok := cryptoRanges[interval]; ok && c.Meta.InstrumentType == "CRYPTOCURRENCY" {
+			interval = rolling
 		}
+		intervals = append(intervals, interval)
 	}
 
 	return intervals
 }
 
+// Intervals returns periods available for every compared symbol.
+func (cmp *Comparison) Intervals() []string {
+	if len(cmp.Charts) == 0 {
+		return nil
+	}
+
+	intervals := cmp.Charts[0].Intervals()
+	for _, c := range cmp.Charts[1:] {
+		available := make(map[string]struct{}, len(intervals))
+		for _, interval := range c.Intervals() {
+			available[interval] = struct{}{}
+		}
+
+		common := intervals[:0]
+		for _, interval := range intervals {
+			if _, ok := available[interval]; ok {
+				common = append(common, interval)
+			}
+		}
+		intervals = common
+	}
+
+	return intervals
+}
+
+func (cmp *Comparison) ChartBytes(p *ChartParams) (tgbot.FileBytes, error) {
+	b, err := cmp.comparisonChart(p)
+	if err != nil {
+		return tgbot.FileBytes{}, err
+	}
+
+	return p.Output.fileBytes(b), nil
+}
+
 func (q *Quote) ChartBytes(p *ChartParams) (tgbot.FileBytes, error) {
-	b, err := q.earningsChart(p)
+	var (
+		b   []byte
+		err error
+	)
+	switch p.Measurement {
+	case "holdings", "sectors":
+		b, err = q.holdingsChart(p)
+	default:
+		b, err = q.earningsChart(p)
+	}
 	if err != nil {
 		return tgbot.FileBytes{}, err
 	}
 
-	return tgbot.FileBytes{
-		Name:  "charts.png",
-		Bytes: b,
-	}, nil
+	return p.Output.fileBytes(b), nil
 }
 
 func (c *Chart) ChartBytes(p *ChartParams) (tgbot.FileBytes, error) {
@@ -126,79 +280,558 @@ func (c *Chart) ChartBytes(p *ChartParams) (tgbot.FileBytes, error) {
 		return tgbot.FileBytes{}, err
 	}
 
-	return tgbot.FileBytes{
-		Name:  "charts.png",
-		Bytes: b,
-	}, nil
+	return p.Output.fileBytes(b), nil
 }
 
+// earningsChart plots earnings or revenue of recent periods, labelled with values and growth over a comparable period.
 func (q *Quote) earningsChart(p *ChartParams) ([]byte, error) {
-	data := make([]chart.Value, 0, 4)
+	bars, growth := q.financialsBars(p.Interval, p.Measurement)
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no %s %s data for symbol: %s", p.Interval, p.Measurement, q.Price.Symbol)
+	}
+
+	graph := createFinancialsChart(fmt.Sprintf("%s %s %s (growth %s)", q.Price.Symbol, p.Interval, p.Measurement, growth), bars, p.Output)
+
+	return p.Output.render(graph)
+}
 
-	switch p.Interval {
+// financialsBars returns figures of every reported period along with their growth over the previous period,
+// and the name of the growth. Yahoo Finance reports only four latest quarters, which is not enough to compare
+// a quarter with the same quarter a year earlier, so quarterly growth is quarter-over-quarter.
+func (q *Quote) financialsBars(interval, measurement string) ([]financialsBar, string) {
+	var bars []financialsBar
+	switch interval {
 	case "yearly":
 		for _, e := range q.Earnings.Chart.Yearly {
-			data = append(data, chart.Value{Value: e.Value(p.Measurement), Label: fmt.Sprintf("%d", int(e.Date))})
+			bars = append(bars, financialsBar{Label: fmt.Sprintf("%d", int(e.Date)), Value: e.Value(measurement)})
 		}
 	case "quarterly":
 		for _, e := range q.Earnings.Chart.Quarterly {
-			data = append(data, chart.Value{Value: e.Value(p.Measurement), Label: e.Date})
+			bars = append(bars, financialsBar{Label: e.Date, Value: e.Value(measurement)})
 		}
 	}
 
-	graph := createBarChart(fmt.Sprintf("%s %s %s", q.Price.Symbol, p.Interval, p.Measurement), data)
+	growth := "YoY"
+	if interval == "quarterly" {
+		growth = "QoQ"
+	}
 
-	buffer := bytes.NewBuffer([]byte{})
-	err := graph.Render(chart.PNG, buffer)
-	if err != nil {
-		return nil, err
+	for i := range bars {
+		bars[i].Growth = math.NaN()
+		if i > 0 && bars[i-1].Value != 0 {
+			prev := bars[i-1].Value
+			bars[i].Growth = (bars[i].Value - prev) / math.Abs(prev) * 100
+		}
 	}
 
-	return buffer.Bytes(), nil
+	return bars, growth
+}
+
+func (q *Quote) holdingsChart(p *ChartParams) ([]byte, error) {
+	var data []chart.Value
+	switch p.Measurement {
+	case "holdings":
+		holdings := q.TopHoldings(10)
+		data = make([]chart.Value, 0, len(holdings)+1)
+		rest := 1.0
+		for _, h := range holdings {
+			data = append(data, chart.Value{Value: h.Percent.Raw, Label: h.Symbol})
+			rest -= h.Percent.Raw
+		}
+		if rest > 0 {
+			data = append(data, chart.Value{Value: rest, Label: "Other"})
+		}
+	case "sectors":
+		sectors := q.SectorWeightings()
+		data = make([]chart.Value, 0, len(sectors))
+		for _, w := range sectors {
+			data = append(data, chart.Value{Value: w.Percent.Raw, Label: w.Name})
+		}
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no %s data for symbol: %s", p.Measurement, q.Price.Symbol)
+	}
+
+	graph := createDonutChart(fmt.Sprintf("%s %s", q.Price.Symbol, p.Measurement), data, p.Output)
+
+	return p.Output.render(graph)
+}
+
+func createDonutChart(name string, data []chart.Value, o ChartOutput) chart.DonutChart {
+	return chart.DonutChart{
+		Title:        name,
+		ColorPalette: o.theme(),
+		Width:        o.size(512),
+		Height:       o.size(512),
+		DPI:          o.dpi(),
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top: o.size(40),
+			},
+		},
+		Values: data,
+	}
 }
 
-func createBarChart(name string, data []chart.Value) chart.BarChart {
-	return chart.BarChart{
-		Title:    name,
-		Width:    512,
-		Height:   384,
-		BarWidth: 40,
+// createFinancialsChart draws a bar per period. Periods are categories, so bars are placed at their indices
+// and the X axis is labelled with period names, with half a bar slot of margin on both sides.
+func createFinancialsChart(name string, bars []financialsBar, o ChartOutput) chart.Chart {
+	theme := o.theme()
+
+	ticks := make([]chart.Tick, 0, len(bars)+2)
+	ticks = append(ticks, chart.Tick{Value: -0.5})
+	minValue, maxValue := 0.0, 0.0
+	for i, b := range bars {
+		ticks = append(ticks, chart.Tick{Value: float64(i), Label: b.Label})
+		minValue = math.Min(minValue, b.Value)
+		maxValue = math.Max(maxValue, b.Value)
+	}
+	ticks = append(ticks, chart.Tick{Value: float64(len(bars)) - 0.5})
+
+	// leave room for value and growth labels above positive bars and below negative ones
+	headroom := (maxValue - minValue) * 0.3
+	if headroom == 0 {
+		headroom = 1
+	}
+	yrange := &chart.ContinuousRange{Min: 0, Max: 0}
+	if minValue < 0 {
+		yrange.Min = minValue - headroom
+	}
+	if maxValue > 0 {
+		yrange.Max = maxValue + headroom
+	}
+
+	return chart.Chart{
+		Title:        name,
+		ColorPalette: theme,
+		Width:        o.size(512),
+		Height:       o.size(384),
+		DPI:          o.dpi(),
 		Background: chart.Style{
 			Padding: chart.Box{
-				Top: 40,
+				Top: o.size(40),
+			},
+		},
+		XAxis: chart.XAxis{
+			Ticks: ticks,
+			TickStyle: chart.Style{
+				FontSize: 10,
+			},
+		},
+		YAxis: chart.YAxis{
+			Range:          yrange,
+			ValueFormatter: amountValueFormatter,
+			TickStyle: chart.Style{
+				FontSize: 10,
+			},
+		},
+		Series: []chart.Series{
+			financialsSeries{
+				Bars:      bars,
+				UpColor:   theme.Up,
+				DownColor: theme.Down,
+				TextColor: theme.Text,
 			},
 		},
-		Bars: data,
 	}
 }
 
+// validate makes sure every price array has a value for each timestamp, so bars can be accessed by index safely.
+func (c *Chart) validate(symbol string, period string) error {
+	if len(c.Timestamps) == 0 || len(c.Indicators.Quote) == 0 || len(c.Indicators.Quote[0].Close) == 0 {
+		return &EmptyDataError{Symbol: symbol, Period: period}
+	}
+
+	q, n := c.Indicators.Quote[0], len(c.Timestamps)
+	if len(q.Open) != n || len(q.High) != n || len(q.Low) != n || len(q.Close) != n || len(q.Volume) != n {
+		return fmt.Errorf("chart data of %s has %d timestamps, but %d/%d/%d/%d/%d open/high/low/close/volume values",
+			symbol, n, len(q.Open), len(q.High), len(q.Low), len(q.Close), len(q.Volume))
+	}
+
+	return nil
+}
+
+// completeBars returns a copy of the chart without bars that have no close price, as there was no trading.
+// Other missing prices of a remaining bar are substituted with its close.
+func (c *Chart) completeBars() *Chart {
+	q := c.Indicators.Quote[0]
+	complete := &Chart{
+		Meta:       c.Meta,
+		Indicators: ChartIndicators{Quote: []ChartQuote{{}}},
+		Timestamps: make([]int, 0, len(c.Timestamps)),
+	}
+
+	fill := func(v, close float64) float64 {
+		if math.IsNaN(v) {
+			return close
+		}
+		return v
+	}
+
+	cq := &complete.Indicators.Quote[0]
+	for i, ts := range c.Timestamps {
+		if math.IsNaN(q.Close[i]) {
+			continue
+		}
+		if i < c.Lookback {
+			complete.Lookback++
+		}
+		complete.Timestamps = append(complete.Timestamps, ts)
+		cq.Open = append(cq.Open, fill(q.Open[i], q.Close[i]))
+		cq.High = append(cq.High, fill(q.High[i], q.Close[i]))
+		cq.Low = append(cq.Low, fill(q.Low[i], q.Close[i]))
+		cq.Close = append(cq.Close, q.Close[i])
+		cq.Volume = append(cq.Volume, q.Volume[i])
+	}
+
+	return complete
+}
+
+// prepend adds bars of history preceding the chart as lookback.
+func (c *Chart) prepend(history *Chart) {
+	if len(c.Timestamps) == 0 || len(c.Indicators.Quote) == 0 || len(history.Indicators.Quote) == 0 {
+		return
+	}
+
+	n := 0
+	for n < len(history.Timestamps) && history.Timestamps[n] < c.Timestamps[0] {
+		n++
+	}
+
+	h, q := history.Indicators.Quote[0], c.Indicators.Quote[0]
+	if len(h.Open) < n || len(h.High) < n || len(h.Low) < n || len(h.Close) < n || len(h.Volume) < n {
+		return
+	}
+
+	c.Timestamps = append(history.Timestamps[:n:n], c.Timestamps...)
+	c.Indicators.Quote[0] = ChartQuote{
+		Open:   append(h.Open[:n:n], q.Open...),
+		High:   append(h.High[:n:n], q.High...),
+		Low:    append(h.Low[:n:n], q.Low...),
+		Close:  append(h.Close[:n:n], q.Close...),
+		Volume: append(h.Volume[:n:n], q.Volume...),
+	}
+	c.Lookback += n
+}
+
+// Closes returns dates and close prices of bars with trades, lookback excluded.
+func (c *Chart) Closes() ([]time.Time, []float64) {
+	if len(c.Indicators.Quote) == 0 {
+		return nil, nil
+	}
+
+	complete := c.completeBars()
+	location := complete.location()
+	dates := make([]time.Time, 0, len(complete.Timestamps)-complete.Lookback)
+	for _, ts := range complete.Timestamps[complete.Lookback:] {
+		dates = append(dates, time.Unix(int64(ts), 0).In(location))
+	}
+
+	return dates, complete.visibleQuote().Close
+}
+
+// LookbackCloses returns close prices of bars with trades preceding the period, e.g. to warm up indicators.
+func (c *Chart) LookbackCloses() []float64 {
+	if len(c.Indicators.Quote) == 0 {
+		return nil
+	}
+
+	complete := c.completeBars()
+
+	return complete.Indicators.Quote[0].Close[:complete.Lookback]
+}
+
+// visibleQuote returns quote bars without lookback.
+func (c *Chart) visibleQuote() ChartQuote {
+	q := c.Indicators.Quote[0]
+
+	return ChartQuote{
+		Open:   q.Open[c.Lookback:],
+		High:   q.High[c.Lookback:],
+		Low:    q.Low[c.Lookback:],
+		Close:  q.Close[c.Lookback:],
+		Volume: q.Volume[c.Lookback:],
+	}
+}
+
+// location returns time zone of the exchange. Fixed offset is a fallback for zone names unknown to tzdata.
+func (c *Chart) location() *time.Location {
+	if c.Meta.Timezone != "" {
+		if location, err := time.LoadLocation(c.Meta.Timezone); err == nil {
+			return location
+		}
+	}
+
+	return time.FixedZone(c.Meta.Timezone, c.Meta.GMTOffset)
+}
+
 func (c *Chart) priceChart(p *ChartParams) ([]byte, error) {
-	dates := make([]time.Time, 0, len(c.Timestamps))
-	for _, ts := range c.Timestamps {
-		dates = append(dates, time.Unix(int64(ts), 0))
+	c = c.completeBars()
+	if len(c.Timestamps) == c.Lookback {
+		return nil, &EmptyDataError{Symbol: p.Symbol, Period: p.Interval}
 	}
 
-	graph := createTSChart(fmt.Sprintf("%s %s (%s)", p.Symbol, p.Measurement, p.Interval),
+	_, interval := periodInterval(p.Interval)
+	intraday := granularities[interval] < 24*time.Hour
+	axis := newTimeAxis(c.Timestamps[c.Lookback:], c.location(), intraday)
+	dates := axis.X
+
+	// indicators are calculated over all closes including lookback, but only visible bars are plotted
+	closes := c.Indicators.Quote[0].Close
+	quote := c.visibleQuote()
+	change, changePercent := c.change(quote, intraday)
+	sign := ""
+	if change >= 0 {
+		sign = "+"
+	}
+	theme := p.Output.theme()
+	graph := createTSChart(fmt.Sprintf("%s %s (%s) %s%s (%+.2f%%)", p.Symbol, p.Measurement, periodTitle(p.Interval), sign, formatPrice(change), changePercent),
 		dates,
-		c.Indicators.Quote[0].High,
+		quote.Close,
+		p.Output,
 	)
+	graph.XAxis.ValueFormatter = axis.Format
 
-	buffer := bytes.NewBuffer([]byte{})
-	err := graph.Render(chart.PNG, buffer)
-	if err != nil {
-		return nil, err
+	if p.Style == "candles" {
+		graph.Series = []chart.Series{
+			candlestickSeries{
+				Name:      graph.Title,
+				XValues:   dates,
+				Open:      quote.Open,
+				High:      quote.High,
+				Low:       quote.Low,
+				Close:     quote.Close,
+				UpColor:   theme.Up,
+				DownColor: theme.Down,
+			},
+		}
 	}
 
-	return buffer.Bytes(), nil
+	overlays := overlaySeries(p, dates, closes, theme)
+	if intraday && c.Meta.PreviousClose > 0 {
+		overlays = append(overlays, chart.TimeSeries{
+			Name: "Prev close",
+			Style: chart.Style{
+				StrokeColor:     theme.Muted,
+				StrokeWidth:     p.Output.stroke(1),
+				StrokeDashArray: []float64{2, 4},
+			},
+			XValues: []time.Time{dates[0], dates[len(dates)-1]},
+			YValues: []float64{c.Meta.PreviousClose, c.Meta.PreviousClose},
+		})
+	}
+
+	if len(overlays) > 0 {
+		graph.Series = append(graph.Series, overlays...)
+		graph.Elements = []chart.Renderable{overlayLegend(overlays, theme)}
+	}
+
+	graph.Series = append(graph.Series, priceAnnotations(dates, quote, p.Style == "candles", theme))
+
+	panels := []chart.Chart{graph}
+	if p.HasOption(volumeOption) && hasVolume(quote.Volume) {
+		panels = append(panels, createVolumeChart(dates, quote, p.Output))
+	}
+
+	if p.HasOption(rsiOption) {
+		if rsi := indicatorSeries(fmt.Sprintf("RSI(%d)", rsiPeriod), dates, ta.RSI(closes, rsiPeriod), chart.Style{
+			StrokeColor: theme.Primary,
+			StrokeWidth: p.Output.stroke(1.5),
+		}); rsi.Len() > 0 {
+			panels = append(panels, createRSIChart(rsi, p.Output))
+		}
+	}
+
+	if p.HasOption(macdOption) {
+		macd, signal, histogram := ta.MACD(closes, macdFast, macdSlow, macdSignal)
+		if h := indicatorSeries("", dates, histogram, chart.Style{}); h.Len() > 0 {
+			panels = append(panels, createMACDChart(
+				histogramSeries{XValues: h.XValues, YValues: h.YValues, UpColor: theme.Up, DownColor: theme.Down},
+				indicatorSeries(fmt.Sprintf("MACD(%d, %d)", macdFast, macdSlow), dates, macd, chart.Style{
+					StrokeColor: theme.Primary,
+					StrokeWidth: p.Output.stroke(1.5),
+				}),
+				indicatorSeries(fmt.Sprintf("Signal(%d)", macdSignal), dates, signal, chart.Style{
+					StrokeColor: theme.Secondary,
+					StrokeWidth: p.Output.stroke(1.5),
+				}),
+				p.Output,
+			))
+		}
+	}
+
+	if len(panels) > 1 {
+		// panels share the time axis, and dates are shown once under the bottom one
+		xrange := &chart.ContinuousRange{
+			Min: chart.TimeToFloat64(dates[0]),
+			Max: chart.TimeToFloat64(dates[len(dates)-1]),
+		}
+		for i := range panels {
+			panels[i].XAxis.Range = xrange
+			panels[i].XAxis.ValueFormatter = axis.Format
+			panels[i].XAxis.Style.Hidden = i != len(panels)-1
+		}
+
+		return renderPanels(p.Output, panels...)
+	}
+
+	return p.Output.render(graph)
+}
+
+// comparisonChart plots every symbol as percent change from the start of the period, so different prices are comparable.
+func (cmp *Comparison) comparisonChart(p *ChartParams) ([]byte, error) {
+	theme := p.Output.theme()
+	series := make([]chart.Series, 0, len(cmp.Charts))
+	symbols := make([]string, 0, len(cmp.Charts))
+	// times are shown in the zone of the first symbol, other exchanges may be in different ones
+	var location *time.Location
+	mixedZones := false
+	for i, c := range cmp.Charts {
+		if len(c.Indicators.Quote) == 0 {
+			continue
+		}
+		if location == nil {
+			location = c.location()
+		} else if c.location().String() != location.String() {
+			mixedZones = true
+		}
+
+		x := make([]time.Time, 0, len(c.Timestamps))
+		y := make([]float64, 0, len(c.Timestamps))
+		var base float64
+		for j, close := range c.Indicators.Quote[0].Close {
+			if math.IsNaN(close) || close <= 0 || j >= len(c.Timestamps) {
+				continue
+			}
+			if base == 0 {
+				base = close
+			}
+			x = append(x, time.Unix(int64(c.Timestamps[j]), 0).In(location))
+			y = append(y, (close/base-1)*100)
+		}
+
+		if len(x) == 0 {
+			continue
+		}
+
+		symbols = append(symbols, c.Meta.Symbol)
+		series = append(series, chart.TimeSeries{
+			Name: c.Meta.Symbol,
+			Style: chart.Style{
+				StrokeColor: theme.GetSeriesColor(i),
+				StrokeWidth: p.Output.stroke(2),
+			},
+			XValues: x,
+			YValues: y,
+		})
+	}
+
+	if len(series) == 0 {
+		return nil, fmt.Errorf("no price data to compare: %s", p.Symbol)
+	}
+	if mixedZones {
+		first := series[0].(chart.TimeSeries)
+		first.Name = fmt.Sprintf("%s, times in %s", first.Name, location)
+		series[0] = first
+	}
+
+	_, interval := periodInterval(p.Interval)
+	graph := createTSChart(fmt.Sprintf("%s (%s)", strings.Join(symbols, " vs "), periodTitle(p.Interval)), nil, nil, p.Output)
+	graph.Series = series
+	graph.XAxis.ValueFormatter = newZoneAxis(location, granularities[interval] < 24*time.Hour).Format
+	graph.YAxis.ValueFormatter = func(v interface{}) string {
+		if f, ok := v.(float64); ok {
+			return fmt.Sprintf("%+.1f%%", f)
+		}
+		return ""
+	}
+	graph.Elements = []chart.Renderable{chart.Legend(&graph, theme.legendStyle())}
+
+	return p.Output.render(graph)
+}
+
+// change returns absolute and percent price change over visible bars.
+// Intraday charts are compared to the previous close, the same way quotes report daily change.
+func (c *Chart) change(q ChartQuote, intraday bool) (float64, float64) {
+	base, last := q.Close[0], q.Close[len(q.Close)-1]
+	if intraday && c.Meta.PreviousClose > 0 {
+		base = c.Meta.PreviousClose
+	}
+
+	return last - base, (last/base - 1) * 100
 }
 
-func createTSChart(name string, x []time.Time, y []float64) chart.Chart {
+// formatPrice rounds a price to a precision meaningful for its magnitude, e.g. sub-1 FX rates and crypto need more decimals.
+func formatPrice(v float64) string {
+	switch abs := math.Abs(v); {
+	case abs >= 1000:
+		return fmt.Sprintf("%.0f", v)
+	case abs >= 1:
+		return fmt.Sprintf("%.2f", v)
+	case abs == 0:
+		return "0"
+	default:
+		// smaller prices keep 4 significant digits, e.g. 0.1234 or 0.00001234
+		return strconv.FormatFloat(v, 'f', 3-int(math.Floor(math.Log10(abs))), 64)
+	}
+}
+
+// priceAnnotations labels the latest price along with the highest and the lowest price over the period.
+// Candles show intrabar extremes, while a line chart plots closes only, so its extremes are taken from closes.
+func priceAnnotations(x []time.Time, q ChartQuote, candles bool, theme ChartTheme) chart.AnnotationSeries {
+	highs, lows := q.Close, q.Close
+	if candles {
+		highs, lows = q.High, q.Low
+	}
+
+	high, low := 0, 0
+	for i := range x {
+		if highs[i] > highs[high] {
+			high = i
+		}
+		if lows[i] < lows[low] {
+			low = i
+		}
+	}
+
+	last := len(x) - 1
+
+	return chart.AnnotationSeries{
+		Style: chart.Style{
+			FillColor:   theme.Background,
+			FontColor:   theme.Text,
+			StrokeColor: theme.Muted,
+		},
+		Annotations: []chart.Value2{
+			{XValue: chart.TimeToFloat64(x[high]), YValue: highs[high], Label: "H " + formatPrice(highs[high])},
+			{XValue: chart.TimeToFloat64(x[low]), YValue: lows[low], Label: "L " + formatPrice(lows[low])},
+			{
+				XValue: chart.TimeToFloat64(x[last]),
+				YValue: q.Close[last],
+				Label:  formatPrice(q.Close[last]),
+				Style: chart.Style{
+					FillColor:   theme.Primary,
+					FontColor:   theme.Background,
+					StrokeColor: theme.Primary,
+				},
+			},
+		},
+	}
+}
+
+func createTSChart(name string, x []time.Time, y []float64, o ChartOutput) chart.Chart {
+	theme := o.theme()
+
 	return chart.Chart{
-		Title:  name,
-		Height: 512,
+		Title:        name,
+		ColorPalette: theme,
+		Width:        o.size(chart.DefaultChartWidth),
+		Height:       o.size(512),
+		DPI:          o.dpi(),
 		Background: chart.Style{
 			Padding: chart.Box{
-				Top: 40,
+				Top: o.size(40),
 			},
 		},
 		XAxis: chart.XAxis{
@@ -216,9 +849,9 @@ func createTSChart(name string, x []time.Time, y []float64) chart.Chart {
 			chart.TimeSeries{
 				Name: name,
 				Style: chart.Style{
-					StrokeColor: chart.ColorBlue,
-					StrokeWidth: 2.5,
-					FillColor:   chart.ColorLightGray,
+					StrokeColor: theme.Primary,
+					StrokeWidth: o.stroke(2.5),
+					FillColor:   theme.Fill,
 				},
 				XValues: x,
 				YValues: y,
@@ -228,65 +861,423 @@ func createTSChart(name string, x []time.Time, y []float64) chart.Chart {
 
 }
 
+func (cr *CrossRates) TableBytes(o ChartOutput) (tgbot.FileBytes, error) {
+	b, err := renderTable(cr.table(), o)
+	if err != nil {
+		return tgbot.FileBytes{}, err
+	}
+
+	return o.fileBytes(b), nil
+}
+
+// renderTable draws a grid of text cells. The first row and column are treated as headers.
+func renderTable(table [][]string, o ChartOutput) ([]byte, error) {
+	const fontSize = 12
+
+	if len(table) == 0 || len(table[0]) == 0 {
+		return nil, fmt.Errorf("table is empty")
+	}
+
+	theme := o.theme()
+	cellWidth, cellHeight, padding := o.size(96), o.size(36), o.size(10)
+	width := cellWidth * len(table[0])
+	height := cellHeight * len(table)
+	r, err := o.renderer()(width, height)
+	if err != nil {
+		return nil, err
+	}
+	r.SetDPI(o.dpi())
+
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return nil, err
+	}
+
+	fillRect(r, 0, 0, width, height, theme.Background)
+	fillRect(r, 0, 0, width, cellHeight, theme.Fill)
+	fillRect(r, 0, 0, cellWidth, height, theme.Fill)
+
+	r.SetStrokeColor(theme.Muted)
+	r.SetStrokeWidth(o.stroke(1))
+	for i := 1; i < len(table); i++ {
+		r.MoveTo(0, i*cellHeight)
+		r.LineTo(width, i*cellHeight)
+	}
+	for j := 1; j < len(table[0]); j++ {
+		r.MoveTo(j*cellWidth, 0)
+		r.LineTo(j*cellWidth, height)
+	}
+	r.Stroke()
+
+	r.SetFont(font)
+	r.SetFontSize(fontSize)
+	r.SetFontColor(theme.Text)
+	for i, row := range table {
+		for j, cell := range row {
+			box := r.MeasureText(cell)
+			x := j*cellWidth + cellWidth - padding - box.Width()
+			if i == 0 || j == 0 {
+				x = j*cellWidth + (cellWidth-box.Width())/2
+			}
+			y := i*cellHeight + (cellHeight+box.Height())/2
+			r.Text(cell, x, y)
+		}
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err = r.Save(buffer); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func fillRect(r chart.Renderer, x, y, width, height int, color drawing.Color) {
+	r.SetFillColor(color)
+	r.MoveTo(x, y)
+	r.LineTo(x+width, y)
+	r.LineTo(x+width, y+height)
+	r.LineTo(x, y+height)
+	r.Close()
+	r.Fill()
+}
+
+// overlaySeries returns technical indicators enabled in chart options, drawn over the price series.
+// Indicators can not be calculated when the range is shorter than their period, such ones are skipped.
+func overlaySeries(p *ChartParams, x []time.Time, closes []float64, theme ChartTheme) []chart.Series {
+	series := make([]chart.Series, 0, 5)
+	add := func(s chart.TimeSeries) {
+		if s.Len() > 0 {
+			series = append(series, s)
+		}
+	}
+
+	if p.HasOption(smaOption) {
+		add(indicatorSeries(fmt.Sprintf("SMA(%d)", smaPeriod), x, ta.SMA(closes, smaPeriod), chart.Style{
+			StrokeColor: theme.Secondary,
+			StrokeWidth: p.Output.stroke(1.5),
+		}))
+	}
+
+	if p.HasOption(emaOption) {
+		add(indicatorSeries(fmt.Sprintf("EMA(%d)", emaPeriod), x, ta.EMA(closes, emaPeriod), chart.Style{
+			StrokeColor: theme.Tertiary,
+			StrokeWidth: p.Output.stroke(1.5),
+		}))
+	}
+
+	if p.HasOption(bollingerOption) {
+		middle, upper, lower := ta.BollingerBands(closes, bollingerPeriod, bollingerDeviations)
+		style := chart.Style{
+			StrokeColor:     theme.Muted,
+			StrokeWidth:     p.Output.stroke(1),
+			StrokeDashArray: []float64{5, 5},
+		}
+		add(indicatorSeries(fmt.Sprintf("BB(%d, %.0f)", bollingerPeriod, bollingerDeviations), x, upper, style))
+		add(indicatorSeries("", x, middle, style))
+		add(indicatorSeries("", x, lower, style))
+	}
+
+	return series
+}
+
+// indicatorSeries aligns indicator values calculated over lookback and visible bars with visible dates,
+// and skips leading values that have no indicator value due to insufficient lookback.
+func indicatorSeries(name string, x []time.Time, y []float64, style chart.Style) chart.TimeSeries {
+	y = y[len(y)-len(x):]
+	start := ta.FirstValid(y)
+
+	return chart.TimeSeries{
+		Name:    name,
+		Style:   style,
+		XValues: x[start:],
+		YValues: y[start:],
+	}
+}
+
+// overlayLegend lists named overlays only, so each indicator appears once and the price series is not repeated.
+func overlayLegend(overlays []chart.Series, theme ChartTheme) chart.Renderable {
+	legend := chart.Chart{}
+	for _, s := range overlays {
+		if s.GetName() != "" {
+			legend.Series = append(legend.Series, s)
+		}
+	}
+
+	return chart.Legend(&legend, theme.legendStyle())
+}
+
+func createRSIChart(rsi chart.TimeSeries, o ChartOutput) chart.Chart {
+	theme := o.theme()
+	first, last := rsi.XValues[0], rsi.XValues[len(rsi.XValues)-1]
+	threshold := func(value float64) chart.TimeSeries {
+		return chart.TimeSeries{
+			Style: chart.Style{
+				StrokeColor:     theme.Muted,
+				StrokeWidth:     o.stroke(1),
+				StrokeDashArray: []float64{5, 5},
+			},
+			XValues: []time.Time{first, last},
+			YValues: []float64{value, value},
+		}
+	}
+
+	return chart.Chart{
+		ColorPalette: theme,
+		Width:        o.size(chart.DefaultChartWidth),
+		Height:       o.size(140),
+		DPI:          o.dpi(),
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top: o.size(10),
+			},
+		},
+		XAxis: chart.XAxis{
+			TickPosition: chart.TickPositionUnderTick,
+			TickStyle: chart.Style{
+				FontSize: 12,
+			},
+		},
+		YAxis: chart.YAxis{
+			Range: &chart.ContinuousRange{
+				Min: 0,
+				Max: 100,
+			},
+			Ticks: []chart.Tick{
+				{Value: 0, Label: "0"},
+				{Value: rsiOversold, Label: fmt.Sprint(rsiOversold)},
+				{Value: rsiOverbought, Label: fmt.Sprint(rsiOverbought)},
+				{Value: 100, Label: "100"},
+			},
+			TickStyle: chart.Style{
+				FontSize: 14,
+			},
+		},
+		Series: []chart.Series{
+			threshold(rsiOversold),
+			threshold(rsiOverbought),
+			rsi,
+		},
+		Elements: []chart.Renderable{overlayLegend([]chart.Series{rsi}, theme)},
+	}
+}
+
+func createMACDChart(histogram histogramSeries, macd, signal chart.TimeSeries, o ChartOutput) chart.Chart {
+	theme := o.theme()
+
+	return chart.Chart{
+		ColorPalette: theme,
+		Width:        o.size(chart.DefaultChartWidth),
+		Height:       o.size(160),
+		DPI:          o.dpi(),
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top: o.size(10),
+			},
+		},
+		XAxis: chart.XAxis{
+			TickPosition: chart.TickPositionUnderTick,
+			TickStyle: chart.Style{
+				FontSize: 12,
+			},
+		},
+		YAxis: chart.YAxis{
+			TickStyle: chart.Style{
+				FontSize: 14,
+			},
+		},
+		Series: []chart.Series{
+			histogram,
+			macd,
+			signal,
+		},
+		Elements: []chart.Renderable{overlayLegend([]chart.Series{macd, signal}, theme)},
+	}
+}
+
+func createVolumeChart(x []time.Time, q ChartQuote, o ChartOutput) chart.Chart {
+	maxVolume := 0
+	for _, v := range q.Volume {
+		if v > maxVolume {
+			maxVolume = v
+		}
+	}
+
+	theme := o.theme()
+
+	return chart.Chart{
+		ColorPalette: theme,
+		Width:        o.size(chart.DefaultChartWidth),
+		Height:       o.size(160),
+		DPI:          o.dpi(),
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top: o.size(10),
+			},
+		},
+		XAxis: chart.XAxis{
+			TickPosition: chart.TickPositionUnderTick,
+			TickStyle: chart.Style{
+				FontSize: 12,
+			},
+		},
+		YAxis: chart.YAxis{
+			Range: &chart.ContinuousRange{
+				Min: 0,
+				Max: float64(maxVolume),
+			},
+			TickStyle: chart.Style{
+				FontSize: 14,
+			},
+		},
+		Series: []chart.Series{
+			volumeSeries{
+				Name:      "Volume",
+				XValues:   x,
+				Volume:    q.Volume,
+				Open:      q.Open,
+				Close:     q.Close,
+				UpColor:   theme.Up,
+				DownColor: theme.Down,
+			},
+		},
+	}
+}
+
+// hasVolume reports whether volume is worth plotting. Currencies and indices report zero volume.
+func hasVolume(volume []int) bool {
+	for _, v := range volume {
+		if v > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
 func ChartKeyboard(p *ChartParams, i []string) *tgbot.InlineKeyboardMarkup {
+	// switching between earnings and revenue keeps the chosen financial period
+	financialsInterval := "quarterly"
+	if p.Interval == "yearly" {
+		financialsInterval = p.Interval
+	}
+
 	firstRow := []tgbot.InlineKeyboardButton{
-		tgbot.NewInlineKeyboardButtonData("Price",
-			fmt.Sprintf("%s|%s|%s|%s|%s",
-				p.Symbol,
-				"1d",
-				"price",
-				"update",
-				p.Type,
-			),
-		),
-		tgbot.NewInlineKeyboardButtonData("Earnings",
-			fmt.Sprintf("%s|%s|%s|%s|%s",
-				p.Symbol,
-				"quarterly",
-				"earnings",
-				"update",
-				p.Type,
-			),
-		),
-		tgbot.NewInlineKeyboardButtonData("Revenue",
-			fmt.Sprintf("%s|%s|%s|%s|%s",
-				p.Symbol,
-				"quarterly",
-				"revenue",
-				"update",
-				p.Type,
-			),
-		),
+		tgbot.NewInlineKeyboardButtonData("Price", p.updateData("1d", "price")),
+		tgbot.NewInlineKeyboardButtonData("Earnings", p.updateData(financialsInterval, "earnings")),
+		tgbot.NewInlineKeyboardButtonData("Revenue", p.updateData(financialsInterval, "revenue")),
 	}
 
 	kb := make([][]tgbot.InlineKeyboardButton, 0, 2)
-	if p.Type == "hasEarnings" {
+	switch p.Type {
+	case "hasEarnings":
 		kb = append(kb, firstRow)
+	case "hasHoldings":
+		kb = append(kb, fundKeyboardRow(p))
+	}
+
+	// fund breakdowns are a point-in-time snapshot, so there are no intervals to switch between
+	if p.Measurement == "holdings" || p.Measurement == "sectors" {
+		i = nil
+	}
+
+	if p.Measurement == "earnings" || p.Measurement == "revenue" {
+		kb = append(kb, financialsPeriodRow(p))
+		i = nil
+	}
+
+	kb = append(kb, splitRow(chartKeyboardSecondRow(p, i), 6)...)
+
+	if p.Measurement == "price" {
+		kb = append(kb, chartStyleRow(p), indicatorsRow(p))
+	}
+
+	return NewCallbackKeyboard(kb)
+}
+
+func fundKeyboardRow(p *ChartParams) []tgbot.InlineKeyboardButton {
+	return []tgbot.InlineKeyboardButton{
+		tgbot.NewInlineKeyboardButtonData("Price", p.updateData("1d", "price")),
+		tgbot.NewInlineKeyboardButtonData("Holdings", p.updateData("-", "holdings")),
+		tgbot.NewInlineKeyboardButtonData("Sectors", p.updateData("-", "sectors")),
+	}
+}
+
+func financialsPeriodRow(p *ChartParams) []tgbot.InlineKeyboardButton {
+	periods := []struct {
+		text     string
+		interval string
+	}{
+		{"Quarterly", "quarterly"},
+		{"Yearly", "yearly"},
+	}
+
+	row := make([]tgbot.InlineKeyboardButton, 0, len(periods))
+	for _, period := range periods {
+		text := period.text
+		if p.Interval == period.interval {
+			text = "✓ " + text
+		}
+		row = append(row, tgbot.NewInlineKeyboardButtonData(text, p.updateData(period.interval, p.Measurement)))
+	}
+
+	return row
+}
+
+func chartStyleRow(p *ChartParams) []tgbot.InlineKeyboardButton {
+	toggle := *p
+	toggle.Action = ActionChartUpdate
+	text := "Candles"
+	toggle.Style = "candles"
+	if p.Style == "candles" {
+		text = "Line"
+		toggle.Style = "line"
+	}
+
+	volumeText := "Show volume"
+	if p.HasOption(volumeOption) {
+		volumeText = "Hide volume"
 	}
 
-	kb = append(kb, chartKeyboardSecondRow(p, i))
+	album := *p
+	album.Action = ActionChartAlbum
 
-	return &tgbot.InlineKeyboardMarkup{
-		InlineKeyboard: kb,
+	return []tgbot.InlineKeyboardButton{
+		tgbot.NewInlineKeyboardButtonData(text, toggle.CallbackData()),
+		tgbot.NewInlineKeyboardButtonData(volumeText, p.toggleData(volumeOption)),
+		tgbot.NewInlineKeyboardButtonData("All ranges", album.CallbackData()),
 	}
 }
 
+func indicatorsRow(p *ChartParams) []tgbot.InlineKeyboardButton {
+	indicators := []struct {
+		text   string
+		option string
+	}{
+		{"SMA", smaOption},
+		{"EMA", emaOption},
+		{"Bollinger", bollingerOption},
+		{"RSI", rsiOption},
+		{"MACD", macdOption},
+	}
+
+	row := make([]tgbot.InlineKeyboardButton, 0, len(indicators))
+	for _, i := range indicators {
+		text := i.text
+		if p.HasOption(i.option) {
+			text = "✓ " + text
+		}
+		row = append(row, tgbot.NewInlineKeyboardButtonData(text, p.toggleData(i.option)))
+	}
+
+	return row
+}
+
 func chartKeyboardSecondRow(p *ChartParams, intervals []string) []tgbot.InlineKeyboardButton {
 	row := make([]tgbot.InlineKeyboardButton, 0, len(intervals))
 
 	for _, interval := range intervals {
-		row = append(row,
-			tgbot.NewInlineKeyboardButtonData(
-				interval,
-				fmt.Sprintf("%s|%s|%s|%s|%s",
-					p.Symbol,
-					interval,
-					p.Measurement,
-					"update",
-					p.Type,
-				),
-			),
-		)
+		row = append(row, tgbot.NewInlineKeyboardButtonData(interval, p.updateData(interval, p.Measurement)))
 	}
 
 	if len(row) <= 1 {
@@ -295,3 +1286,14 @@ func chartKeyboardSecondRow(p *ChartParams, intervals []string) []tgbot.InlineKe
 
 	return row
 }
+
+// splitRow breaks a long row of buttons into rows of at most n buttons, so labels are not cut on narrow screens.
+func splitRow(row []tgbot.InlineKeyboardButton, n int) [][]tgbot.InlineKeyboardButton {
+	rows := make([][]tgbot.InlineKeyboardButton, 0, len(row)/n+1)
+	for len(row) > n {
+		rows = append(rows, row[:n])
+		row = row[n:]
+	}
+
+	return append(rows, row)
+}
@@ -0,0 +1,52 @@
+package yfapi
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// matches "100 usd eur", "100 usd to eur" and "100.5USD in EUR"
+	conversionRe = regexp.MustCompile(`(?i)^\s*([0-9]+(?:[.,][0-9]+)?)\s*([a-z]{3})\s+(?:(?:to|in|into)\s+)?([a-z]{3})\s*$`)
+)
+
+const baseCurrency = "USD"
+
+type Conversion struct {
+	Amount float64
+	From   string
+	To     string
+	Rate   float64
+	Time   time.Time
+	// Via is set to the intermediate currency when there is no direct pair for From and To
+	Via string
+}
+
+func (c *Conversion) Result() float64 {
+	return c.Amount * c.Rate
+}
+
+// ParseConversion extracts amount and currencies from text like "100 usd to eur".
+func ParseConversion(text string) (float64, string, string, bool) {
+	m := conversionRe.FindStringSubmatch(text)
+	if m == nil {
+		return 0, "", "", false
+	}
+
+	amount, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+	if err != nil {
+		return 0, "", "", false
+	}
+
+	return amount, strings.ToUpper(m[2]), strings.ToUpper(m[3]), true
+}
+
+func currencySymbol(from, to string) string {
+	if from == baseCurrency {
+		return to + "=X"
+	}
+
+	return from + to + "=X"
+}
@@ -0,0 +1,112 @@
+package yfapi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	maxCrossRateCurrencies = 8
+	// widest monospace table that fits into a message bubble on a phone without wrapping
+	maxCrossRateTextWidth = 36
+)
+
+// CrossRates holds exchange rates between every pair of currencies.
+// Rates[i][j] is the price of one unit of Currencies[i] in Currencies[j].
+type CrossRates struct {
+	Currencies []string
+	Rates      [][]float64
+	Time       time.Time
+}
+
+func ParseCurrencies(text string) []string {
+	fields := strings.Fields(strings.ToUpper(text))
+	currencies := make([]string, 0, len(fields))
+	seen := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		if len(f) != 3 {
+			continue
+		}
+		if _, ok := seen[f]; ok {
+			continue
+		}
+		seen[f] = struct{}{}
+		currencies = append(currencies, f)
+	}
+
+	return currencies
+}
+
+func newCrossRates(currencies []string, usdRates map[string]float64, ts time.Time) *CrossRates {
+	rates := make([][]float64, len(currencies))
+	for i, from := range currencies {
+		rates[i] = make([]float64, len(currencies))
+		for j, to := range currencies {
+			rates[i][j] = usdRates[to] / usdRates[from]
+		}
+	}
+
+	return &CrossRates{
+		Currencies: currencies,
+		Rates:      rates,
+		Time:       ts,
+	}
+}
+
+// FitsText reports whether the table is narrow enough to be sent as a monospace message.
+func (cr *CrossRates) FitsText() bool {
+	width := 0
+	for _, w := range textColumnWidths(cr.table()) {
+		width += w
+	}
+
+	return width <= maxCrossRateTextWidth
+}
+
+// textColumnWidths returns widths of monospace table columns: the longest cell of each column,
+// plus a separating space before every column but the first one.
+func textColumnWidths(table [][]string) []int {
+	var widths []int
+	for _, row := range table {
+		for i, cell := range row {
+			w := len([]rune(cell))
+			if i > 0 {
+				w++
+			}
+			if i == len(widths) {
+				widths = append(widths, w)
+			} else if w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	return widths
+}
+
+func formatRate(rate float64) string {
+	switch {
+	case rate >= 1000:
+		return fmt.Sprintf("%.1f", rate)
+	case rate >= 1:
+		return fmt.Sprintf("%.4f", rate)
+	default:
+		return fmt.Sprintf("%.6f", rate)
+	}
+}
+
+func (cr *CrossRates) table() [][]string {
+	table := make([][]string, 0, len(cr.Currencies)+1)
+	table = append(table, append([]string{""}, cr.Currencies...))
+	for i, from := range cr.Currencies {
+		row := make([]string, 0, len(cr.Currencies)+1)
+		row = append(row, from)
+		for j := range cr.Currencies {
+			row = append(row, formatRate(cr.Rates[i][j]))
+		}
+		table = append(table, row)
+	}
+
+	return table
+}
@@ -0,0 +1,360 @@
+package yfapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+const (
+	maxHeatmapSymbols = 50
+	// day change at which tiles get the full up or down colour
+	heatmapChangeRange = 3.0
+
+	heatmapWidth  = 1024
+	heatmapHeight = 640
+)
+
+// heatmapLists are named symbol lists, e.g. index constituents, which can be passed to /heatmap instead of symbols.
+// Defaults are replaced by LoadHeatmapLists.
+var heatmapLists = map[string][]string{
+	"DOW": {
+		"AAPL", "AMGN", "AXP", "BA", "CAT", "CRM", "CSCO", "CVX", "DIS", "DOW",
+		"GS", "HD", "HON", "IBM", "INTC", "JNJ", "JPM", "KO", "MCD", "MMM",
+		"MRK", "MSFT", "NKE", "PG", "TRV", "UNH", "V", "VZ", "WBA", "WMT",
+	},
+	"FAANG":  {"META", "AAPL", "AMZN", "NFLX", "GOOGL"},
+	"CRYPTO": {"BTC-USD", "ETH-USD", "BNB-USD", "XRP-USD", "ADA-USD", "SOL-USD", "DOGE-USD", "DOT-USD"},
+	"SECTORS": {
+		"XLK", "XLF", "XLV", "XLY", "XLP", "XLE", "XLI", "XLB", "XLU", "XLRE", "XLC",
+	},
+}
+
+// Heatmap is a set of quotes drawn as a treemap: tiles are sized by market cap and coloured by day change.
+type Heatmap struct {
+	Quotes []*Quote
+	// Missing are requested symbols without a quote, they are left out of the heatmap
+	Missing []string
+}
+
+// LoadHeatmapLists replaces default symbol lists with ones from a JSON file of {"NAME": ["SYMBOL", ...]} form.
+func LoadHeatmapLists(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	lists := make(map[string][]string)
+	if err = json.NewDecoder(f).Decode(&lists); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	heatmapLists = make(map[string][]string, len(lists))
+	for name, symbols := range lists {
+		heatmapLists[strings.ToUpper(name)] = symbols
+	}
+
+	return nil
+}
+
+// HeatmapLists returns sorted names of symbol lists known to /heatmap.
+func HeatmapLists() []string {
+	names := make([]string, 0, len(heatmapLists))
+	for name := range heatmapLists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ParseHeatmapSymbols returns symbols of a named list, or symbols listed in text without duplicates.
+func ParseHeatmapSymbols(text string) []string {
+	fields := strings.Fields(strings.ToUpper(text))
+	if len(fields) == 1 {
+		if symbols, ok := heatmapLists[fields[0]]; ok {
+			return symbols
+		}
+	}
+
+	symbols := make([]string, 0, len(fields))
+	seen := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		if _, ok := seen[f]; ok {
+			continue
+		}
+		seen[f] = struct{}{}
+		symbols = append(symbols, f)
+	}
+
+	return symbols
+}
+
+// GetHeatmap fetches quotes of all symbols in one batch request.
+func (c *YFClient) GetHeatmap(symbols []string) (*Heatmap, error) {
+	if len(symbols) < 2 || len(symbols) > maxHeatmapSymbols {
+		return nil, &QueryError{
+			Code:        "Bad Request",
+			Description: fmt.Sprintf("Provide from 2 to %d symbols or one of lists: %s", maxHeatmapSymbols, strings.Join(HeatmapLists(), ", ")),
+		}
+	}
+
+	quotes, err := c.GetQuotes(symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	known := quotes[:0]
+	found := make(map[string]struct{}, len(quotes))
+	for _, q := range quotes {
+		if q.Price.MarketPrice.Raw != 0 {
+			known = append(known, q)
+			found[strings.ToUpper(q.Symbol())] = struct{}{}
+		}
+	}
+	if len(known) == 0 {
+		return nil, &QueryError{
+			Code:        "Not Found",
+			Description: "No quotes found for: " + strings.Join(symbols, " "),
+		}
+	}
+
+	h := &Heatmap{Quotes: known}
+	for _, s := range symbols {
+		if _, ok := found[strings.ToUpper(s)]; !ok {
+			h.Missing = append(h.Missing, s)
+		}
+	}
+
+	return h, nil
+}
+
+// HeatmapCaption names the largest gainer and loser of the heatmap, along with symbols left out of it.
+func (h *Heatmap) HeatmapCaption() string {
+	best, worst := h.Quotes[0], h.Quotes[0]
+	for _, q := range h.Quotes {
+		if q.Price.ChangePercent.Raw > best.Price.ChangePercent.Raw {
+			best = q
+		}
+		if q.Price.ChangePercent.Raw < worst.Price.ChangePercent.Raw {
+			worst = q
+		}
+	}
+
+	caption := fmt.Sprintf("%d symbols, best %s %+.2f%%, worst %s %+.2f%%",
+		len(h.Quotes), best.Symbol(), best.Price.ChangePercent.Raw, worst.Symbol(), worst.Price.ChangePercent.Raw)
+	if len(h.Missing) > 0 {
+		caption += "\nNot found: " + strings.Join(h.Missing, " ")
+	}
+
+	return caption
+}
+
+// heatmapTile is a rectangle of the treemap in image coordinates.
+type heatmapTile struct {
+	X, Y, Width, Height float64
+}
+
+// heatmapWeights returns tile areas of the quotes. Symbols without market cap, e.g. ETFs,
+// get the smallest cap of the heatmap, or equal tiles if no symbol has one.
+func heatmapWeights(quotes []*Quote) []float64 {
+	smallest := math.Inf(1)
+	for _, q := range quotes {
+		if marketCap := q.Price.MarketCap.Raw; marketCap > 0 {
+			smallest = math.Min(smallest, marketCap)
+		}
+	}
+	if math.IsInf(smallest, 1) {
+		smallest = 1
+	}
+
+	weights := make([]float64, len(quotes))
+	for i, q := range quotes {
+		weights[i] = q.Price.MarketCap.Raw
+		if weights[i] <= 0 {
+			weights[i] = smallest
+		}
+	}
+
+	return weights
+}
+
+// squarify lays out weights sorted in descending order into a rectangle, keeping tiles as close to squares as possible.
+// See "Squarified Treemaps" by Bruls, Huizing and van Wijk.
+func squarify(weights []float64, area heatmapTile) []heatmapTile {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+
+	areas := make([]float64, len(weights))
+	for i, w := range weights {
+		areas[i] = w / total * area.Width * area.Height
+	}
+
+	// worst returns the largest aspect ratio of a row of areas laid along a side
+	worst := func(row []float64, side float64) float64 {
+		sum, lo, hi := 0.0, math.Inf(1), 0.0
+		for _, a := range row {
+			sum += a
+			lo, hi = math.Min(lo, a), math.Max(hi, a)
+		}
+		return math.Max(side*side*hi/(sum*sum), sum*sum/(side*side*lo))
+	}
+
+	tiles := make([]heatmapTile, 0, len(areas))
+	layout := func(row []float64) {
+		sum := 0.0
+		for _, a := range row {
+			sum += a
+		}
+
+		// rows are laid along the shorter side of the remaining area
+		if area.Width >= area.Height {
+			width, y := sum/area.Height, area.Y
+			for _, a := range row {
+				tiles = append(tiles, heatmapTile{X: area.X, Y: y, Width: width, Height: a / width})
+				y += a / width
+			}
+			area.X += width
+			area.Width -= width
+		} else {
+			height, x := sum/area.Width, area.X
+			for _, a := range row {
+				tiles = append(tiles, heatmapTile{X: x, Y: area.Y, Width: a / height, Height: height})
+				x += a / height
+			}
+			area.Y += height
+			area.Height -= height
+		}
+	}
+
+	var row []float64
+	for _, a := range areas {
+		side := math.Min(area.Width, area.Height)
+		if len(row) == 0 || worst(append(row, a), side) <= worst(row, side) {
+			row = append(row, a)
+			continue
+		}
+		layout(row)
+		row = []float64{a}
+	}
+	if len(row) > 0 {
+		layout(row)
+	}
+
+	return tiles
+}
+
+// changeColor blends the neutral colour towards up or down colour of the theme by the day change.
+func changeColor(change float64, theme ChartTheme) drawing.Color {
+	target := theme.Up
+	if change < 0 {
+		target = theme.Down
+	}
+
+	return blendColor(theme.Muted, target, math.Abs(change)/heatmapChangeRange)
+}
+
+// blendColor returns an opaque colour t of the way from one colour to another, t is clamped to [0, 1].
+func blendColor(from, to drawing.Color, t float64) drawing.Color {
+	t = math.Max(0, math.Min(t, 1))
+	blend := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+
+	return drawing.Color{
+		R: blend(from.R, to.R),
+		G: blend(from.G, to.G),
+		B: blend(from.B, to.B),
+		A: 255,
+	}
+}
+
+// HeatmapBytes renders the heatmap, the largest tiles are in the top left corner.
+func (h *Heatmap) HeatmapBytes(o ChartOutput) (tgbot.FileBytes, error) {
+	theme := o.theme()
+	width, height := o.size(heatmapWidth), o.size(heatmapHeight)
+
+	r, err := o.renderer()(width, height)
+	if err != nil {
+		return tgbot.FileBytes{}, err
+	}
+	r.SetDPI(o.dpi())
+
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return tgbot.FileBytes{}, err
+	}
+	r.SetFont(font)
+
+	fillRect(r, 0, 0, width, height, theme.Background)
+
+	quotes := make([]*Quote, len(h.Quotes))
+	copy(quotes, h.Quotes)
+	weights := heatmapWeights(quotes)
+	sort.Sort(byWeight{quotes, weights})
+
+	tiles := squarify(weights, heatmapTile{Width: float64(width), Height: float64(height)})
+	gap := o.size(1)
+	for i, tile := range tiles {
+		q := quotes[i]
+		x, y := int(math.Round(tile.X)), int(math.Round(tile.Y))
+		w, ht := int(math.Round(tile.X+tile.Width))-x, int(math.Round(tile.Y+tile.Height))-y
+		fillRect(r, x+gap, y+gap, w-2*gap, ht-2*gap, changeColor(q.Price.ChangePercent.Raw, theme))
+
+		// label size follows the tile, labels which do not fit are left out
+		size := math.Max(8, math.Min(24, math.Min(float64(w), float64(ht))/float64(o.size(4))))
+		r.SetFontSize(size)
+		r.SetFontColor(chart.ColorWhite)
+		symbol := r.MeasureText(q.Symbol())
+		if symbol.Width() > w-2*gap || symbol.Height() > ht-2*gap {
+			continue
+		}
+
+		r.SetFontSize(size * 0.75)
+		change := fmt.Sprintf("%+.2f%%", q.Price.ChangePercent.Raw)
+		changeBox := r.MeasureText(change)
+		lines := symbol.Height() + changeBox.Height() + o.size(4)
+		if changeBox.Width() > w-2*gap || lines > ht-2*gap {
+			r.SetFontSize(size)
+			r.Text(q.Symbol(), x+(w-symbol.Width())/2, y+(ht+symbol.Height())/2)
+			continue
+		}
+
+		top := y + (ht-lines)/2
+		r.SetFontSize(size)
+		r.Text(q.Symbol(), x+(w-symbol.Width())/2, top+symbol.Height())
+		r.SetFontSize(size * 0.75)
+		r.Text(change, x+(w-changeBox.Width())/2, top+lines)
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err = r.Save(buffer); err != nil {
+		return tgbot.FileBytes{}, err
+	}
+
+	return o.fileBytes(buffer.Bytes()), nil
+}
+
+// byWeight sorts quotes along with their weights in descending order, as squarify expects.
+type byWeight struct {
+	quotes  []*Quote
+	weights []float64
+}
+
+func (s byWeight) Len() int           { return len(s.quotes) }
+func (s byWeight) Less(i, j int) bool { return s.weights[i] > s.weights[j] }
+func (s byWeight) Swap(i, j int) {
+	s.quotes[i], s.quotes[j] = s.quotes[j], s.quotes[i]
+	s.weights[i], s.weights[j] = s.weights[j], s.weights[i]
+}
@@ -3,8 +3,12 @@ package yfapi
 import (
 	"fmt"
 	"html"
+	"math"
+	"strconv"
+	"strings"
 
 	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"quote-telegram-bot/pkg/helpers"
 )
 
 func (q *Quote) WebsiteButton() tgbot.InlineKeyboardButton {
@@ -23,17 +27,25 @@ func (q *Quote) ChartsButton() tgbot.InlineKeyboardButton {
 	t := "hasNoEarnings"
 	if len(q.Earnings.Chart.Quarterly) > 0 || len(q.Earnings.Chart.Yearly) > 0 {
 		t = "hasEarnings"
+	} else if q.HasHoldings() {
+		t = "hasHoldings"
 	}
 
-	return tgbot.NewInlineKeyboardButtonData("Charts",
-		fmt.Sprintf("%s|%s|%s|%s|%s",
-			q.Price.Symbol,
-			"1d",
-			"price",
-			"initial",
-			t,
-		),
-	)
+	interval := "1d"
+	if q.Type() == "CRYPTOCURRENCY" {
+		interval = cryptoRanges[interval]
+	}
+
+	params := ChartParams{
+		Symbol:      q.Price.Symbol,
+		Interval:    interval,
+		Measurement: "price",
+		Action:      ActionChart,
+		Type:        t,
+		Style:       defaultChartStyle,
+	}
+
+	return tgbot.NewInlineKeyboardButtonData("Charts", params.CallbackData())
 }
 
 func (q *Quote) StandardMessageInlineKeyboard() *tgbot.InlineKeyboardMarkup {
@@ -114,30 +126,229 @@ func (q *Quote) StandardMessage() string {
 			"Return(YTD)      %s\n"+
 			"Return(Avg, 3Y)  %s\n"+
 			"Return(Avg, 5Y)  %s\n"+
-			"```",
+			"```"+
+			"%s",
+			q.Name(),
+			q.Symbol(),
+			q.Exchange(),
+			q.MarketPrice(),
+			q.Category(),
+			q.Type(),
+			q.Beta(),
+			q.Assets(),
+			q.ExpenseRatio(),
+			q.Return("YTD"),
+			q.Return("3Y"),
+			q.Return("5Y"),
+			q.HoldingsMessage(),
+		)
+	case "MUTUALFUND":
+		msg = fmt.Sprintf("*%s (%s:%s) %s*\n"+
+			"_%s %s_\n\n"+
+			"```\n"+
+			"NAV:             %s\n"+
+			"Change:          %s\n"+
+			"Fund Family:     %s\n"+
+			"Beta(3Y):        %s\n"+
+			"Assets:          %s\n"+
+			"Expense Ratio:   %s\n"+
+			"Yield:           %s\n"+
+			"Return(YTD)      %s\n"+
+			"Return(Avg, 3Y)  %s\n"+
+			"Return(Avg, 5Y)  %s\n"+
+			"```"+
+			"%s",
 			q.Name(),
 			q.Symbol(),
 			q.Exchange(),
 			q.MarketPrice(),
 			q.Category(),
 			q.Type(),
+			q.NAV(),
+			q.Change(),
+			q.FundFamily(),
 			q.Beta(),
 			q.Assets(),
 			q.ExpenseRatio(),
+			q.Yield(),
 			q.Return("YTD"),
 			q.Return("3Y"),
 			q.Return("5Y"),
+			q.HoldingsMessage(),
 		)
-	case "CURRENCY", "CRYPTOCURRENCY":
-		msg = fmt.Sprintf("*%s %s*\n",
+	case "INDEX":
+		msg = fmt.Sprintf("*%s (%s:%s) %s*\n"+
+			"_%s_\n\n"+
+			"```\n"+
+			"Change:      %s\n"+
+			"Open:        %s\n"+
+			"Prev Close:  %s\n"+
+			"Day Range:   %s\n"+
+			"52W Range:   %s\n"+
+			"```",
 			q.Name(),
+			q.Symbol(),
+			q.Exchange(),
 			q.MarketPrice(),
+			q.Type(),
+			q.Change(),
+			q.Open(),
+			q.PreviousClose(),
+			q.DayRange(),
+			q.FiftyTwoWeekRange(),
+		)
+	case "FUTURE":
+		msg = fmt.Sprintf("*%s (%s:%s) %s*\n"+
+			"_%s_\n\n"+
+			"```\n"+
+			"Change:         %s\n"+
+			"Open:           %s\n"+
+			"Prev Close:     %s\n"+
+			"Day Range:      %s\n"+
+			"52W Range:      %s\n"+
+			"Open Interest:  %s\n"+
+			"Expires:        %s\n"+
+			"```",
+			q.Name(),
+			q.Symbol(),
+			q.Exchange(),
+			q.MarketPrice(),
+			q.Type(),
+			q.Change(),
+			q.Open(),
+			q.PreviousClose(),
+			q.DayRange(),
+			q.FiftyTwoWeekRange(),
+			q.OpenInterest(),
+			q.ExpireDate(),
+		)
+	case "CURRENCY":
+		msg = fmt.Sprintf("*%s %s*\n\n"+
+			"```\n"+
+			"Change:      %s\n"+
+			"Day Range:   %s\n"+
+			"52W Range:   %s\n\n"+
+			"%-12s %s\n"+
+			"```",
+			q.Name(),
+			q.MarketPrice(),
+			q.Change(),
+			q.DayRange(),
+			q.FiftyTwoWeekRange(),
+			q.InversePair()+":",
+			q.InverseRate(),
+		)
+	case "CRYPTOCURRENCY":
+		msg = fmt.Sprintf("*%s %s*\n\n"+
+			"```\n"+
+			"Change:        %s\n"+
+			"Day Range:     %s\n"+
+			"52W Range:     %s\n\n"+
+			"Volume(24h):   %s\n"+
+			"MarketCap:     %s\n"+
+			"Circulating:   %s\n"+
+			"```",
+			q.Name(),
+			q.MarketPrice(),
+			q.Change(),
+			q.DayRange(),
+			q.FiftyTwoWeekRange(),
+			q.Volume24Hr(),
+			q.MarketCap(),
+			q.CirculatingSupply(),
 		)
 	}
 
 	return msg
 }
 
+func (q *Quote) HoldingsMessage() string {
+	if !q.HasHoldings() {
+		return ""
+	}
+
+	var sb strings.Builder
+	if holdings := q.TopHoldings(10); len(holdings) > 0 {
+		sb.WriteString("\n*Top holdings*\n```\n")
+		for _, h := range holdings {
+			sb.WriteString(fmt.Sprintf("%-8s %-20s %7s\n", h.Symbol, helpers.Truncate(h.Name, 20), h.Percent.Fmt))
+		}
+		sb.WriteString("```")
+	}
+
+	if sectors := q.SectorWeightings(); len(sectors) > 0 {
+		sb.WriteString("\n*Sectors*\n```\n")
+		for _, w := range sectors {
+			sb.WriteString(fmt.Sprintf("%-23s %7s\n", w.Name, w.Percent.Fmt))
+		}
+		sb.WriteString("```")
+	}
+
+	if classes := q.AssetClasses(); len(classes) > 0 {
+		sb.WriteString("\n*Asset classes*\n```\n")
+		for _, w := range classes {
+			sb.WriteString(fmt.Sprintf("%-23s %7s\n", w.Name, w.Percent.Fmt))
+		}
+		sb.WriteString("```")
+	}
+
+	return sb.String()
+}
+
+func (c *Conversion) ConversionMessage() string {
+	via := ""
+	if c.Via != "" {
+		via = " via " + c.Via
+	}
+
+	return fmt.Sprintf("*%s %s = %s %s*\n"+
+		"_1 %s = %s %s%s, as of %s_",
+		strconv.FormatFloat(c.Amount, 'f', -1, 64),
+		c.From,
+		formatAmount(c.Result()),
+		c.To,
+		c.From,
+		strconv.FormatFloat(c.Rate, 'f', 6, 64),
+		c.To,
+		via,
+		c.Time.UTC().Format("2006-01-02 15:04 MST"),
+	)
+}
+
+// formatAmount keeps cents of amounts of at least 1, smaller ones are rounded to significant digits the same way as prices.
+func formatAmount(v float64) string {
+	if math.Abs(v) >= 1 {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+
+	return formatPrice(v)
+}
+
+func (cr *CrossRates) CrossRatesMessage() string {
+	var sb strings.Builder
+	sb.WriteString("*Cross rates*\n```\n")
+	table := cr.table()
+	widths := textColumnWidths(table)
+	for _, row := range table {
+		for i, cell := range row {
+			if i == 0 {
+				sb.WriteString(fmt.Sprintf("%-*s", widths[i], cell))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("%*s", widths[i], cell))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```\n")
+	sb.WriteString(cr.CrossRatesCaption())
+
+	return sb.String()
+}
+
+func (cr *CrossRates) CrossRatesCaption() string {
+	return fmt.Sprintf("_1 unit of row currency in column currency, as of %s_", cr.Time.UTC().Format("2006-01-02 15:04 MST"))
+}
+
 func HelpMessage(lang string) string {
 	var msg string
 	hand := html.UnescapeString("&#" + "128071" + ";")
@@ -146,14 +357,40 @@ func HelpMessage(lang string) string {
 		msg = "Я могу:\n" +
 			"- найти тикер по названию компании(используя команду вида /name)\n" +
 			"- найти базовые показатели и графики компании или фонда по тикеру(например AAPL или VOO)\n" +
+			"- показать прибыль и выручку по кварталам с ростом к предыдущему кварталу и по годам с ростом к предыдущему году\n" +
 			"- найти курс обмена валют (например RUB=X для курса USD/RUB, либо USDRUB=X/RUBUSD=X для конкретной пары)\n" +
+			"- сконвертировать сумму в другую валюту (например /convert 100 USD EUR или 100 usd to eur)\n" +
+			"- построить таблицу кросс-курсов (например /fx USD EUR GBP JPY)\n" +
+			"- построить тепловую карту рынка по капитализации и изменению за день (например /heatmap AAPL MSFT GOOGL или /heatmap DOW)\n" +
+			"- сравнить динамику цен нескольких тикеров (например /compare AAPL MSFT QQQ)\n" +
+			"- построить матрицу корреляций дневных доходностей (например /correlation AAPL MSFT GLD 5y)\n" +
+			"- построить график цены за произвольный период (например /chart AAPL 2020-01-01 2020-12-31)\n" +
+			"- посчитать доходность, волатильность, просадку, коэффициенты Шарпа и Сортино и бету (например /stats AAPL 5y)\n" +
+			"- кратко описать технические индикаторы: тренд, RSI, MACD, уровни поддержки и сопротивления (например /ta AAPL)\n" +
+			"- протестировать простую стратегию на истории цен (например /backtest SPY sma 50 200 2010)\n" +
+			"- выгрузить график в SVG (например /export AAPL 1y)\n" +
+			"- сменить тему графиков (/theme light, dark или contrast) и их размер (например /size 2)\n" +
+			"- получать котировки карточкой с графиком (/format card) или текстом (/format text)\n" +
 			"Список бирж и их суффиксов: [yahoo finance knowledge base](https://help.yahoo.com/kb/exchanges-data-providers-yahoo-finance-sln2310.html)\n\n" +
 			"Попробуй отправить мне тикер AAPL или команду для поиска /tesla" + hand
 	default:
 		msg = "I can:\n" +
 			"- find stock symbol by company name(using command like /name)" +
 			"- find basic financial indicators of arbitrary stock symbol(e.g. AAPL or VOO)\n" +
+			"- show earnings and revenue by quarter with quarter-over-quarter growth, and by year with year-over-year growth\n" +
 			"- find currency exchange ratio (e.g. RUB=X for USD/RUB pair, or USDRUB=X/RUBUSD=X for specific pair)\n" +
+			"- convert an amount to another currency (e.g. /convert 100 USD EUR or 100 usd to eur)\n" +
+			"- build a cross-rate table (e.g. /fx USD EUR GBP JPY)\n" +
+			"- draw a market heatmap sized by market cap and coloured by day change (e.g. /heatmap AAPL MSFT GOOGL or /heatmap DOW)\n" +
+			"- compare price changes of several symbols (e.g. /compare AAPL MSFT QQQ)\n" +
+			"- build a correlation matrix of daily returns (e.g. /correlation AAPL MSFT GLD 5y)\n" +
+			"- plot price chart over arbitrary dates (e.g. /chart AAPL 2020-01-01 2020-12-31)\n" +
+			"- calculate return, volatility, drawdown, Sharpe and Sortino ratios and beta (e.g. /stats AAPL 5y)\n" +
+			"- summarize technical indicators: trend, RSI, MACD, support and resistance levels (e.g. /ta AAPL)\n" +
+			"- backtest a simple strategy over price history (e.g. /backtest SPY sma 50 200 2010)\n" +
+			"- export price chart as SVG (e.g. /export AAPL 1y)\n" +
+			"- change chart theme (/theme light, dark or contrast) and size (e.g. /size 2)\n" +
+			"- get quotes as an image card with a sparkline (/format card) or as text (/format text)\n" +
 			"Exchanges and data providers list: [yahoo finance knowledge base](https://help.yahoo.com/kb/exchanges-data-providers-yahoo-finance-sln2310.html)\n\n" +
 			"Try to send me symbol AAPL or search command /tesla" + hand
 	}
@@ -0,0 +1,140 @@
+package yfapi
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/wcharczuk/go-chart/v2"
+)
+
+// renderPanels stacks charts vertically into one image. Canvas boxes of all panels are aligned horizontally,
+// so series sharing X values line up across panels regardless of their Y axis label widths.
+func renderPanels(o ChartOutput, panels ...chart.Chart) ([]byte, error) {
+	if err := alignPanels(panels); err != nil {
+		return nil, err
+	}
+
+	width, height := 0, 0
+	for _, p := range panels {
+		if p.GetWidth() > width {
+			width = p.GetWidth()
+		}
+		height += p.GetHeight()
+	}
+
+	if o.isSVG() {
+		return stackSVG(width, height, panels)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(o.theme().Background), image.Point{}, draw.Src)
+
+	top := 0
+	for _, p := range panels {
+		buffer := bytes.NewBuffer([]byte{})
+		if err := p.Render(chart.PNG, buffer); err != nil {
+			return nil, err
+		}
+
+		img, err := png.Decode(buffer)
+		if err != nil {
+			return nil, err
+		}
+
+		draw.Draw(canvas, img.Bounds().Add(image.Point{Y: top}), img, image.Point{}, draw.Over)
+		top += p.GetHeight()
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err := png.Encode(buffer, canvas); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// stackSVG nests SVG documents of panels into one, each shifted down by the height of panels above it.
+func stackSVG(width, height int, panels []chart.Chart) ([]byte, error) {
+	buffer := bytes.NewBuffer([]byte{})
+	fmt.Fprintf(buffer, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%d" height="%d">`, width, height)
+
+	top := 0
+	for _, p := range panels {
+		panel := bytes.NewBuffer([]byte{})
+		if err := p.Render(chart.SVG, panel); err != nil {
+			return nil, err
+		}
+
+		buffer.Write(bytes.Replace(panel.Bytes(), []byte("<svg "), []byte(fmt.Sprintf(`<svg y="%d" `, top)), 1))
+		top += p.GetHeight()
+	}
+	buffer.WriteString("</svg>")
+
+	return buffer.Bytes(), nil
+}
+
+// alignPanels pads every panel so all canvas boxes share the same left and right edges.
+// Canvas box depends on axis labels, which may shift slightly once padding changes, hence a couple of passes.
+func alignPanels(panels []chart.Chart) error {
+	for pass := 0; pass < 3; pass++ {
+		boxes := make([]chart.Box, len(panels))
+		left, right := 0, -1
+		for i, p := range panels {
+			box, err := panelCanvasBox(p)
+			if err != nil {
+				return err
+			}
+			boxes[i] = box
+			if box.Left > left {
+				left = box.Left
+			}
+			if right < 0 || box.Right < right {
+				right = box.Right
+			}
+		}
+
+		aligned := true
+		for i := range panels {
+			dl, dr := left-boxes[i].Left, boxes[i].Right-right
+			if dl == 0 && dr == 0 {
+				continue
+			}
+			aligned = false
+
+			padding := panels[i].Background.Padding
+			panels[i].Background.Padding = chart.Box{
+				Top:    padding.GetTop(chart.DefaultBackgroundPadding.Top),
+				Left:   padding.GetLeft(chart.DefaultBackgroundPadding.Left) + dl,
+				Right:  padding.GetRight(chart.DefaultBackgroundPadding.Right) + dr,
+				Bottom: padding.GetBottom(chart.DefaultBackgroundPadding.Bottom),
+				IsSet:  true,
+			}
+		}
+
+		if aligned {
+			break
+		}
+	}
+
+	return nil
+}
+
+// panelCanvasBox renders the chart without output to find out where go-chart places its canvas.
+func panelCanvasBox(c chart.Chart) (chart.Box, error) {
+	var box chart.Box
+	elements := make([]chart.Renderable, 0, len(c.Elements)+1)
+	elements = append(elements, c.Elements...)
+	c.Elements = append(elements, func(r chart.Renderer, canvasBox chart.Box, defaults chart.Style) {
+		box = canvasBox
+	})
+
+	if err := c.Render(chart.PNG, io.Discard); err != nil {
+		return chart.Box{}, err
+	}
+
+	return box, nil
+}
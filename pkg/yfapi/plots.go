@@ -0,0 +1,212 @@
+package yfapi
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"time"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/wcharczuk/go-chart/v2"
+)
+
+// StatsRanges are periods statistics over daily returns are calculated for, shorter periods have too few days.
+var StatsRanges = []string{"3mo", "6mo", "ytd", "1y", "2y", "5y", "10y", "max"}
+
+// HasStatsRange reports whether statistics can be calculated over the period.
+func HasStatsRange(period string) bool {
+	for _, r := range StatsRanges {
+		if r == period {
+			return true
+		}
+	}
+
+	return false
+}
+
+// percentValueFormatter formats axis values which are already in percent.
+func percentValueFormatter(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return fmt.Sprintf("%.0f%%", f)
+	}
+	return ""
+}
+
+// DrawdownChartBytes plots decline of a price from its running peak in percent, the deepest one is labelled.
+func DrawdownChartBytes(p *ChartParams, dates []time.Time, drawdown []float64) (tgbot.FileBytes, error) {
+	if len(dates) == 0 || len(dates) != len(drawdown) {
+		return tgbot.FileBytes{}, &EmptyDataError{Symbol: p.Symbol, Period: p.Interval}
+	}
+
+	theme := p.Output.theme()
+	graph := createTSChart(fmt.Sprintf("%s drawdown (%s)", p.Symbol, periodTitle(p.Interval)), nil, nil, p.Output)
+	graph.YAxis.ValueFormatter = percentValueFormatter
+
+	deepest := 0
+	for i, d := range drawdown {
+		if d < drawdown[deepest] {
+			deepest = i
+		}
+	}
+
+	graph.Series = []chart.Series{
+		chart.TimeSeries{
+			Name: "Drawdown",
+			Style: chart.Style{
+				StrokeColor: theme.Down,
+				StrokeWidth: p.Output.stroke(1.5),
+				FillColor:   theme.Down.WithAlpha(64),
+			},
+			XValues: dates,
+			YValues: drawdown,
+		},
+		chart.AnnotationSeries{
+			Style: chart.Style{
+				FillColor:   theme.Background,
+				FontColor:   theme.Text,
+				StrokeColor: theme.Muted,
+			},
+			Annotations: []chart.Value2{{
+				XValue: chart.TimeToFloat64(dates[deepest]),
+				YValue: drawdown[deepest],
+				Label:  fmt.Sprintf("%.1f%% %s", drawdown[deepest], dates[deepest].Format("2006-01-02")),
+			}},
+		},
+	}
+
+	b, err := p.Output.render(graph)
+	if err != nil {
+		return tgbot.FileBytes{}, err
+	}
+
+	return p.Output.fileBytes(b), nil
+}
+
+// MatrixBytes draws a square matrix of values from -1 to 1, e.g. correlations, with cells coloured from down colour
+// of the theme through background to up colour. Labels name both rows and columns.
+func MatrixBytes(title string, labels []string, values [][]float64, o ChartOutput) (tgbot.FileBytes, error) {
+	const (
+		cellSize    = 72
+		labelWidth  = 96
+		titleHeight = 48
+		// cells of small matrices are enlarged, so the title fits
+		minWidth = 400
+	)
+
+	if len(labels) == 0 || len(values) != len(labels) {
+		return tgbot.FileBytes{}, fmt.Errorf("matrix is empty")
+	}
+
+	theme := o.theme()
+	size := cellSize
+	if n := len(labels); labelWidth+size*n < minWidth {
+		size = (minWidth - labelWidth) / n
+	}
+	cell, label, top := o.size(size), o.size(labelWidth), o.size(titleHeight)
+	width := label + cell*len(labels)
+	height := top + cell*(len(labels)+1)
+
+	r, err := o.renderer()(width, height)
+	if err != nil {
+		return tgbot.FileBytes{}, err
+	}
+	r.SetDPI(o.dpi())
+
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return tgbot.FileBytes{}, err
+	}
+	r.SetFont(font)
+
+	fillRect(r, 0, 0, width, height, theme.Background)
+
+	// centered draws text in the middle of a box
+	centered := func(s string, x, y, w, h int) {
+		box := r.MeasureText(s)
+		r.Text(s, x+(w-box.Width())/2, y+(h+box.Height())/2)
+	}
+
+	r.SetFontColor(theme.Text)
+	r.SetFontSize(16)
+	centered(title, 0, 0, width, top)
+
+	r.SetFontSize(12)
+	for i, l := range labels {
+		centered(l, label+i*cell, top, cell, cell)
+		centered(l, 0, top+(i+1)*cell, label, cell)
+	}
+
+	// gaps between cells form the grid
+	fillRect(r, label, top+cell, cell*len(labels), cell*len(labels), theme.Muted)
+	for i, row := range values {
+		for j, v := range row {
+			x, y := label+j*cell, top+(i+1)*cell
+			text := "N/A"
+			color := theme.Background
+			if !math.IsNaN(v) {
+				text = fmt.Sprintf("%.2f", v)
+				color = blendColor(theme.Background, theme.Up, v)
+				if v < 0 {
+					color = blendColor(theme.Background, theme.Down, -v)
+				}
+			}
+			fillRect(r, x+1, y+1, cell-2, cell-2, color)
+
+			// strong values are on saturated cells, which need a light text
+			r.SetFontColor(theme.Text)
+			if math.Abs(v) > 0.6 {
+				r.SetFontColor(chart.ColorWhite)
+			}
+			centered(text, x, y, cell, cell)
+		}
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	if err = r.Save(buffer); err != nil {
+		return tgbot.FileBytes{}, err
+	}
+
+	return o.fileBytes(buffer.Bytes()), nil
+}
+
+// Curve is a named line of an equity chart.
+type Curve struct {
+	Name   string
+	Values []float64
+}
+
+// EquityChartBytes plots growth of invested amounts over time, e.g. a strategy against buy and hold.
+func EquityChartBytes(title string, dates []time.Time, curves []Curve, o ChartOutput) (tgbot.FileBytes, error) {
+	if len(dates) == 0 || len(curves) == 0 {
+		return tgbot.FileBytes{}, fmt.Errorf("no equity to plot: %s", title)
+	}
+
+	theme := o.theme()
+	graph := createTSChart(title, nil, nil, o)
+	graph.Series = make([]chart.Series, 0, len(curves))
+	for i, c := range curves {
+		graph.Series = append(graph.Series, chart.TimeSeries{
+			Name: c.Name,
+			Style: chart.Style{
+				StrokeColor: theme.GetSeriesColor(i),
+				StrokeWidth: o.stroke(2),
+			},
+			XValues: dates,
+			YValues: c.Values,
+		})
+	}
+	graph.YAxis.ValueFormatter = func(v interface{}) string {
+		if f, ok := v.(float64); ok {
+			return fmt.Sprintf("%.0f", f)
+		}
+		return ""
+	}
+	graph.Elements = []chart.Renderable{chart.Legend(&graph, theme.legendStyle())}
+
+	b, err := o.render(graph)
+	if err != nil {
+		return tgbot.FileBytes{}, err
+	}
+
+	return o.fileBytes(b), nil
+}
@@ -2,6 +2,25 @@ package yfapi
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	sectorNames = map[string]string{
+		"realestate":             "Real Estate",
+		"consumer_cyclical":      "Consumer Cyclical",
+		"basic_materials":        "Basic Materials",
+		"consumer_defensive":     "Consumer Defensive",
+		"technology":             "Technology",
+		"communication_services": "Communication Services",
+		"financial_services":     "Financial Services",
+		"utilities":              "Utilities",
+		"industrials":            "Industrials",
+		"energy":                 "Energy",
+		"healthcare":             "Healthcare",
+	}
 )
 
 type Quote struct {
@@ -11,6 +30,8 @@ type Quote struct {
 	Statistics   QuoteStatistics
 	Financials   QuoteFinancials
 	Earnings     QuoteEarnings
+	Holdings     QuoteHoldings
+	Summary      QuoteSummaryDetail
 }
 
 type QuoteResponse struct {
@@ -24,6 +45,17 @@ type QuoteSummary struct {
 
 type QuoteData = []map[string]map[string]interface{}
 
+type BatchResponse struct {
+	Data BatchSummary `json:"quoteResponse"`
+}
+
+type BatchSummary struct {
+	Data  BatchData  `json:"result"`
+	Error QueryError `json:"error"`
+}
+
+type BatchData = []map[string]interface{}
+
 type QueryError struct {
 	Code        string `json:"code"`
 	Description string `json:"description"`
@@ -33,6 +65,28 @@ func (e *QueryError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Code, e.Description)
 }
 
+// EmptyDataError is returned when Yahoo Finance has no data points for a symbol over requested period,
+// e.g. for a range before listing or a symbol that is no longer traded.
+type EmptyDataError struct {
+	Symbol string
+	Period string
+}
+
+func (e *EmptyDataError) Error() string {
+	return fmt.Sprintf("No data for %s over %s", e.Symbol, periodTitle(e.Period))
+}
+
+// InsufficientDataError is returned when price history is too short for indicators or statistics, e.g. of a recent listing.
+type InsufficientDataError struct {
+	Symbol   string
+	Days     int
+	Required int
+}
+
+func (e *InsufficientDataError) Error() string {
+	return fmt.Sprintf("Not enough price history for %s: %d days, at least %d needed", e.Symbol, e.Days, e.Required)
+}
+
 type IndicatorValue struct {
 	Raw float64 `mapstructure:"raw"`
 	Fmt string  `mapstructure:"fmt"`
@@ -48,6 +102,13 @@ type QuotePrice struct {
 	Exchange       string         `mapstructure:"exchangeName"`
 	MarketCap      IndicatorValue `mapstructure:"marketCap"`
 	MarketPrice    IndicatorValue `mapstructure:"regularMarketPrice"`
+	Change         IndicatorValue `mapstructure:"regularMarketChange"`
+	ChangePercent  IndicatorValue `mapstructure:"regularMarketChangePercent"`
+	Open           IndicatorValue `mapstructure:"regularMarketOpen"`
+	PreviousClose  IndicatorValue `mapstructure:"regularMarketPreviousClose"`
+	DayHigh        IndicatorValue `mapstructure:"regularMarketDayHigh"`
+	DayLow         IndicatorValue `mapstructure:"regularMarketDayLow"`
+	MarketTime     int64          `mapstructure:"regularMarketTime"`
 }
 
 // https://query1.finance.yahoo.com/v11/finance/quoteSummary/${QUOTE}?modules=assetProfile
@@ -57,14 +118,30 @@ type QuoteAssetProfile struct {
 	Website  string `mapstructure:"website"`
 }
 
+// https://query1.finance.yahoo.com/v11/finance/quoteSummary/${QUOTE}?modules=fundProfile
 type QuoteFundProfile struct {
-	Fees QuoteFundFees `mapstructure:"feesExpensesInvestment"`
+	Family   string        `mapstructure:"family"`
+	Category string        `mapstructure:"categoryName"`
+	Fees     QuoteFundFees `mapstructure:"feesExpensesInvestment"`
 }
 
 type QuoteFundFees struct {
 	ExpenseRatio IndicatorValue `mapstructure:"annualReportExpenseRatio"`
 }
 
+// https://query1.finance.yahoo.com/v11/finance/quoteSummary/${QUOTE}?modules=summaryDetail
+type QuoteSummaryDetail struct {
+	FiftyTwoWeekHigh IndicatorValue `mapstructure:"fiftyTwoWeekHigh"`
+	FiftyTwoWeekLow  IndicatorValue `mapstructure:"fiftyTwoWeekLow"`
+	Volume           IndicatorValue `mapstructure:"volume"`
+	NAV              IndicatorValue `mapstructure:"navPrice"`
+	Yield            IndicatorValue `mapstructure:"yield"`
+	ExpireDate       IndicatorValue `mapstructure:"expireDate"`
+	OpenInterest     IndicatorValue `mapstructure:"openInterest"`
+	Volume24Hr       IndicatorValue `mapstructure:"volume24Hr"`
+	Circulating      IndicatorValue `mapstructure:"circulatingSupply"`
+}
+
 // https://query1.finance.yahoo.com/v11/finance/quoteSummary/${QUOTE}?modules=defaultKeyStatistics
 type QuoteStatistics struct {
 	EV           IndicatorValue `mapstructure:"enterpriseValue"`
@@ -103,6 +180,30 @@ type QuoteEarnings struct {
 	Currency string          `mapstructure:"financialCurrency"`
 }
 
+// https://query1.finance.yahoo.com/v11/finance/quoteSummary/${QUOTE}?modules=topHoldings
+type QuoteHoldings struct {
+	Holdings         []FundHolding               `mapstructure:"holdings"`
+	SectorWeightings []map[string]IndicatorValue `mapstructure:"sectorWeightings"`
+	Stocks           IndicatorValue              `mapstructure:"stockPosition"`
+	Bonds            IndicatorValue              `mapstructure:"bondPosition"`
+	Cash             IndicatorValue              `mapstructure:"cashPosition"`
+	Preferred        IndicatorValue              `mapstructure:"preferredPosition"`
+	Convertible      IndicatorValue              `mapstructure:"convertiblePosition"`
+	Other            IndicatorValue              `mapstructure:"otherPosition"`
+}
+
+type FundHolding struct {
+	Symbol  string         `mapstructure:"symbol"`
+	Name    string         `mapstructure:"holdingName"`
+	Percent IndicatorValue `mapstructure:"holdingPercent"`
+}
+
+// FundWeight is a single named share of fund assets, used for sector and asset class breakdowns.
+type FundWeight struct {
+	Name    string
+	Percent IndicatorValue
+}
+
 type FinancialsChart struct {
 	Quarterly []QuarterlyFinancialsChart `mapstructure:"quarterly"`
 	Yearly    []YearlyFinancialsChart    `mapstructure:"yearly"`
@@ -299,7 +400,7 @@ func (q *Quote) Beta() string {
 	switch q.Price.Type {
 	case "EQUITY":
 		beta = q.Statistics.Beta.Fmt
-	case "ETF":
+	case "ETF", "MUTUALFUND":
 		beta = q.Statistics.Beta3Y.Fmt
 	default:
 		beta = "N/A"
@@ -351,11 +452,15 @@ func (q *Quote) SectorIndustry() string {
 }
 
 func (q *Quote) Category() string {
-	if q.Statistics.Category == "" {
-		return "Unknown category"
+	if q.Statistics.Category != "" {
+		return q.Statistics.Category
+	}
+
+	if q.FundProfile.Category != "" {
+		return q.FundProfile.Category
 	}
 
-	return q.Statistics.Category
+	return "Unknown category"
 }
 
 func (q *Quote) Exchange() string {
@@ -377,3 +482,199 @@ func (q *Quote) Type() string {
 func (q *Quote) Intervals() []string {
 	return []string{"quarterly", "yearly"}
 }
+
+func (q *Quote) HasHoldings() bool {
+	return len(q.Holdings.Holdings) > 0 || len(q.Holdings.SectorWeightings) > 0
+}
+
+// TopHoldings returns at most n fund holdings in the order reported by Yahoo Finance (by weight, descending).
+func (q *Quote) TopHoldings(n int) []FundHolding {
+	if len(q.Holdings.Holdings) < n {
+		n = len(q.Holdings.Holdings)
+	}
+
+	return q.Holdings.Holdings[:n]
+}
+
+// SectorWeightings returns non-zero sector weights sorted by weight, descending.
+func (q *Quote) SectorWeightings() []FundWeight {
+	weights := make([]FundWeight, 0, len(q.Holdings.SectorWeightings))
+	for _, sw := range q.Holdings.SectorWeightings {
+		for sector, value := range sw {
+			if value.Raw <= 0 {
+				continue
+			}
+			name, ok := sectorNames[sector]
+			if !ok {
+				name = sector
+			}
+			weights = append(weights, FundWeight{Name: name, Percent: value})
+		}
+	}
+
+	sort.SliceStable(weights, func(i, j int) bool {
+		return weights[i].Percent.Raw > weights[j].Percent.Raw
+	})
+
+	return weights
+}
+
+// AssetClasses returns non-zero asset class positions of a fund.
+func (q *Quote) AssetClasses() []FundWeight {
+	positions := []FundWeight{
+		{Name: "Stocks", Percent: q.Holdings.Stocks},
+		{Name: "Bonds", Percent: q.Holdings.Bonds},
+		{Name: "Cash", Percent: q.Holdings.Cash},
+		{Name: "Preferred", Percent: q.Holdings.Preferred},
+		{Name: "Convertible", Percent: q.Holdings.Convertible},
+		{Name: "Other", Percent: q.Holdings.Other},
+	}
+
+	classes := make([]FundWeight, 0, len(positions))
+	for _, p := range positions {
+		if p.Percent.Raw > 0 {
+			classes = append(classes, p)
+		}
+	}
+
+	return classes
+}
+
+func (q *Quote) Change() string {
+	if q.Price.Change.Fmt == "" || q.Price.ChangePercent.Fmt == "" {
+		return "N/A"
+	}
+
+	sign := ""
+	if q.Price.Change.Raw > 0 {
+		sign = "+"
+	}
+
+	return sign + q.Price.Change.Fmt + " (" + sign + q.Price.ChangePercent.Fmt + ")"
+}
+
+func (q *Quote) Open() string {
+	if q.Price.Open.Fmt == "" {
+		return "N/A"
+	}
+
+	return q.Price.CurrencySymbol + q.Price.Open.Fmt
+}
+
+func (q *Quote) PreviousClose() string {
+	if q.Price.PreviousClose.Fmt == "" {
+		return "N/A"
+	}
+
+	return q.Price.CurrencySymbol + q.Price.PreviousClose.Fmt
+}
+
+func (q *Quote) DayRange() string {
+	if q.Price.DayLow.Fmt == "" || q.Price.DayHigh.Fmt == "" {
+		return "N/A"
+	}
+
+	return q.Price.DayLow.Fmt + " - " + q.Price.DayHigh.Fmt
+}
+
+func (q *Quote) FiftyTwoWeekRange() string {
+	if q.Summary.FiftyTwoWeekLow.Fmt == "" || q.Summary.FiftyTwoWeekHigh.Fmt == "" {
+		return "N/A"
+	}
+
+	return q.Summary.FiftyTwoWeekLow.Fmt + " - " + q.Summary.FiftyTwoWeekHigh.Fmt
+}
+
+// NAV returns net asset value per share of a fund. Mutual funds are priced once a day at NAV,
+// so market price is used when summaryDetail has no separate NAV.
+func (q *Quote) NAV() string {
+	if q.Summary.NAV.Fmt == "" {
+		return q.MarketPrice()
+	}
+
+	return q.Price.CurrencySymbol + q.Summary.NAV.Fmt
+}
+
+func (q *Quote) FundFamily() string {
+	if q.FundProfile.Family == "" {
+		return "N/A"
+	}
+
+	return q.FundProfile.Family
+}
+
+func (q *Quote) Yield() string {
+	if q.Summary.Yield.Fmt == "" {
+		return "N/A"
+	}
+
+	return q.Summary.Yield.Fmt
+}
+
+func (q *Quote) ExpireDate() string {
+	if q.Summary.ExpireDate.Fmt == "" {
+		return "N/A"
+	}
+
+	return q.Summary.ExpireDate.Fmt
+}
+
+func (q *Quote) OpenInterest() string {
+	if q.Summary.OpenInterest.Fmt == "" {
+		return "N/A"
+	}
+
+	return q.Summary.OpenInterest.Fmt
+}
+
+func (q *Quote) Volume24Hr() string {
+	if q.Summary.Volume24Hr.Fmt == "" {
+		return "N/A"
+	}
+
+	return q.Price.CurrencySymbol + q.Summary.Volume24Hr.Fmt
+}
+
+func (q *Quote) CirculatingSupply() string {
+	if q.Summary.Circulating.Fmt == "" {
+		return "N/A"
+	}
+
+	return q.Summary.Circulating.Fmt
+}
+
+// CurrencyPair returns base and quote currencies of a currency quote.
+// Yahoo Finance uses RUB=X notation for USD/RUB and EURRUB=X for any other pair.
+func (q *Quote) CurrencyPair() (string, string) {
+	return ParseCurrencyPair(q.Price.Symbol)
+}
+
+// InversePair returns a name of the currency pair with base and quote currencies swapped.
+func (q *Quote) InversePair() string {
+	base, quote := q.CurrencyPair()
+	if base == "" || quote == "" {
+		return "Inverse"
+	}
+
+	return quote + "/" + base
+}
+
+func (q *Quote) InverseRate() string {
+	if q.Price.MarketPrice.Raw == 0 {
+		return "N/A"
+	}
+
+	return strconv.FormatFloat(1/q.Price.MarketPrice.Raw, 'f', 6, 64)
+}
+
+func ParseCurrencyPair(symbol string) (string, string) {
+	pair := strings.TrimSuffix(strings.ToUpper(symbol), "=X")
+	switch len(pair) {
+	case 3:
+		return "USD", pair
+	case 6:
+		return pair[:3], pair[3:]
+	}
+
+	return "", ""
+}
@@ -10,6 +10,9 @@ var (
 		"ETF":            {},
 		"CURRENCY":       {},
 		"CRYPTOCURRENCY": {},
+		"MUTUALFUND":     {},
+		"INDEX":          {},
+		"FUTURE":         {},
 	}
 )
 
@@ -47,7 +50,7 @@ func (r *SearchResponse) SearchMessageInlineKeyboard() *tgbot.InlineKeyboardMark
 			buttons = append(buttons,
 				tgbot.NewInlineKeyboardButtonData(
 					res.Symbol,
-					res.Symbol,
+					QuoteCallbackData(res.Symbol),
 				),
 			)
 		}
@@ -57,7 +60,5 @@ func (r *SearchResponse) SearchMessageInlineKeyboard() *tgbot.InlineKeyboardMark
 		}
 	}
 
-	return &tgbot.InlineKeyboardMarkup{
-		InlineKeyboard: rows,
-	}
+	return NewCallbackKeyboard(rows)
 }
@@ -0,0 +1,380 @@
+package yfapi
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// candlestickSeries draws OHLC bars as candles: a wick from low to high and a body from open to close,
+// UpColor when price went up and DownColor otherwise.
+type candlestickSeries struct {
+	Name    string
+	Style   chart.Style
+	XValues []time.Time
+	Open    []float64
+	High    []float64
+	Low     []float64
+	Close   []float64
+
+	UpColor   drawing.Color
+	DownColor drawing.Color
+}
+
+func (cs candlestickSeries) GetName() string {
+	return cs.Name
+}
+
+func (cs candlestickSeries) GetStyle() chart.Style {
+	return cs.Style
+}
+
+func (cs candlestickSeries) GetYAxis() chart.YAxisType {
+	return chart.YAxisPrimary
+}
+
+func (cs candlestickSeries) Len() int {
+	return len(cs.XValues)
+}
+
+// GetBoundedValues lets the chart fit its Y range to wicks rather than to close prices.
+func (cs candlestickSeries) GetBoundedValues(index int) (x, y1, y2 float64) {
+	return chart.TimeToFloat64(cs.XValues[index]), cs.Low[index], cs.High[index]
+}
+
+func (cs candlestickSeries) GetValueFormatters() (x, y chart.ValueFormatter) {
+	return chart.TimeValueFormatter, chart.FloatValueFormatter
+}
+
+func (cs candlestickSeries) Validate() error {
+	n := len(cs.XValues)
+	if n == 0 {
+		return fmt.Errorf("candlestick series must have x values")
+	}
+	if len(cs.Open) != n || len(cs.High) != n || len(cs.Low) != n || len(cs.Close) != n {
+		return fmt.Errorf("candlestick series must have open, high, low and close for every x value")
+	}
+
+	return nil
+}
+
+func (cs candlestickSeries) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, defaults chart.Style) {
+	if cs.Len() == 0 {
+		return
+	}
+
+	// candles take 60% of the space available to each bar, but are always at least one pixel wide
+	bodyWidth := int(math.Max(1, 0.6*float64(canvasBox.Width())/float64(cs.Len())))
+	for i := range cs.XValues {
+		x := canvasBox.Left + xrange.Translate(chart.TimeToFloat64(cs.XValues[i]))
+		high := canvasBox.Bottom - yrange.Translate(cs.High[i])
+		low := canvasBox.Bottom - yrange.Translate(cs.Low[i])
+		open := canvasBox.Bottom - yrange.Translate(cs.Open[i])
+		closed := canvasBox.Bottom - yrange.Translate(cs.Close[i])
+
+		color := cs.UpColor
+		if cs.Close[i] < cs.Open[i] {
+			color = cs.DownColor
+		}
+
+		r.SetStrokeColor(color)
+		r.SetStrokeWidth(1)
+		r.MoveTo(x, high)
+		r.LineTo(x, low)
+		r.Stroke()
+
+		top, bottom := open, closed
+		if top > bottom {
+			top, bottom = bottom, top
+		}
+		if bottom == top {
+			bottom++
+		}
+
+		fillRect(r, x-bodyWidth/2, top, bodyWidth, bottom-top, color)
+	}
+}
+
+// volumeSeries draws traded volume as bars coloured by the direction of the price move within each bar.
+type volumeSeries struct {
+	Name    string
+	Style   chart.Style
+	XValues []time.Time
+	Volume  []int
+	Open    []float64
+	Close   []float64
+
+	UpColor   drawing.Color
+	DownColor drawing.Color
+}
+
+func (vs volumeSeries) GetName() string {
+	return vs.Name
+}
+
+func (vs volumeSeries) GetStyle() chart.Style {
+	return vs.Style
+}
+
+func (vs volumeSeries) GetYAxis() chart.YAxisType {
+	return chart.YAxisPrimary
+}
+
+func (vs volumeSeries) Len() int {
+	return len(vs.XValues)
+}
+
+func (vs volumeSeries) GetValues(index int) (x, y float64) {
+	return chart.TimeToFloat64(vs.XValues[index]), float64(vs.Volume[index])
+}
+
+func (vs volumeSeries) GetValueFormatters() (x, y chart.ValueFormatter) {
+	return chart.TimeValueFormatter, volumeValueFormatter
+}
+
+func (vs volumeSeries) Validate() error {
+	n := len(vs.XValues)
+	if n == 0 {
+		return fmt.Errorf("volume series must have x values")
+	}
+	if len(vs.Volume) != n || len(vs.Open) != n || len(vs.Close) != n {
+		return fmt.Errorf("volume series must have volume, open and close for every x value")
+	}
+
+	return nil
+}
+
+func (vs volumeSeries) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, defaults chart.Style) {
+	if vs.Len() == 0 {
+		return
+	}
+
+	barWidth := int(math.Max(1, 0.6*float64(canvasBox.Width())/float64(vs.Len())))
+	for i := range vs.XValues {
+		x := canvasBox.Left + xrange.Translate(chart.TimeToFloat64(vs.XValues[i]))
+		top := canvasBox.Bottom - yrange.Translate(float64(vs.Volume[i]))
+
+		color := vs.UpColor.WithAlpha(160)
+		if vs.Close[i] < vs.Open[i] {
+			color = vs.DownColor.WithAlpha(160)
+		}
+
+		fillRect(r, x-barWidth/2, top, barWidth, canvasBox.Bottom-top, color)
+	}
+}
+
+func volumeValueFormatter(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return formatVolume(f)
+	}
+
+	return chart.FloatValueFormatter(v)
+}
+
+// amountValueFormatter formats money amounts, which unlike volume may be negative, e.g. a net loss.
+func amountValueFormatter(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		if f < 0 {
+			return "-" + formatVolume(-f)
+		}
+		return formatVolume(f)
+	}
+
+	return chart.FloatValueFormatter(v)
+}
+
+func formatVolume(v float64) string {
+	switch {
+	case v >= 1e9:
+		return fmt.Sprintf("%.1fB", v/1e9)
+	case v >= 1e6:
+		return fmt.Sprintf("%.1fM", v/1e6)
+	case v >= 1e3:
+		return fmt.Sprintf("%.1fK", v/1e3)
+	default:
+		return fmt.Sprintf("%.0f", v)
+	}
+}
+
+// histogramSeries draws values as bars growing from zero, UpColor for positive values and DownColor for negative ones.
+type histogramSeries struct {
+	Name    string
+	Style   chart.Style
+	XValues []time.Time
+	YValues []float64
+
+	UpColor   drawing.Color
+	DownColor drawing.Color
+}
+
+func (hs histogramSeries) GetName() string {
+	return hs.Name
+}
+
+func (hs histogramSeries) GetStyle() chart.Style {
+	return hs.Style
+}
+
+func (hs histogramSeries) GetYAxis() chart.YAxisType {
+	return chart.YAxisPrimary
+}
+
+func (hs histogramSeries) Len() int {
+	return len(hs.XValues)
+}
+
+// GetBoundedValues keeps zero within the Y range, since every bar starts from it.
+func (hs histogramSeries) GetBoundedValues(index int) (x, y1, y2 float64) {
+	return chart.TimeToFloat64(hs.XValues[index]), math.Min(0, hs.YValues[index]), math.Max(0, hs.YValues[index])
+}
+
+func (hs histogramSeries) GetValueFormatters() (x, y chart.ValueFormatter) {
+	return chart.TimeValueFormatter, chart.FloatValueFormatter
+}
+
+func (hs histogramSeries) Validate() error {
+	if len(hs.XValues) == 0 {
+		return fmt.Errorf("histogram series must have x values")
+	}
+	if len(hs.YValues) != len(hs.XValues) {
+		return fmt.Errorf("histogram series must have y value for every x value")
+	}
+
+	return nil
+}
+
+func (hs histogramSeries) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, defaults chart.Style) {
+	if hs.Len() == 0 {
+		return
+	}
+
+	barWidth := int(math.Max(1, 0.6*float64(canvasBox.Width())/float64(hs.Len())))
+	zero := canvasBox.Bottom - yrange.Translate(0)
+	for i := range hs.XValues {
+		x := canvasBox.Left + xrange.Translate(chart.TimeToFloat64(hs.XValues[i]))
+		y := canvasBox.Bottom - yrange.Translate(hs.YValues[i])
+
+		color := hs.UpColor.WithAlpha(160)
+		top, height := y, zero-y
+		if hs.YValues[i] < 0 {
+			color = hs.DownColor.WithAlpha(160)
+			top, height = zero, y-zero
+		}
+
+		fillRect(r, x-barWidth/2, top, barWidth, height, color)
+	}
+}
+
+// financialsBar is earnings or revenue of a single period. Growth is NaN when there is nothing to compare with.
+type financialsBar struct {
+	Label  string
+	Value  float64
+	Growth float64
+}
+
+// financialsSeries draws a bar growing from zero for every period, placed at its index on the X axis.
+// Bars are labelled with values and growth, losses are drawn in DownColor.
+type financialsSeries struct {
+	Name  string
+	Style chart.Style
+	Bars  []financialsBar
+
+	UpColor   drawing.Color
+	DownColor drawing.Color
+	TextColor drawing.Color
+}
+
+func (fs financialsSeries) GetName() string {
+	return fs.Name
+}
+
+func (fs financialsSeries) GetStyle() chart.Style {
+	return fs.Style
+}
+
+func (fs financialsSeries) GetYAxis() chart.YAxisType {
+	return chart.YAxisPrimary
+}
+
+func (fs financialsSeries) Len() int {
+	return len(fs.Bars)
+}
+
+// GetBoundedValues keeps zero within the Y range, since every bar starts from it.
+func (fs financialsSeries) GetBoundedValues(index int) (x, y1, y2 float64) {
+	v := fs.Bars[index].Value
+	return float64(index), math.Min(0, v), math.Max(0, v)
+}
+
+func (fs financialsSeries) GetValueFormatters() (x, y chart.ValueFormatter) {
+	return chart.FloatValueFormatter, amountValueFormatter
+}
+
+func (fs financialsSeries) Validate() error {
+	if len(fs.Bars) == 0 {
+		return fmt.Errorf("financials series must have bars")
+	}
+
+	return nil
+}
+
+func (fs financialsSeries) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, defaults chart.Style) {
+	if fs.Len() == 0 {
+		return
+	}
+
+	barWidth := int(math.Max(1, 0.5*float64(canvasBox.Width())/float64(fs.Len())))
+	zero := canvasBox.Bottom - yrange.Translate(0)
+
+	r.SetFont(defaults.GetFont())
+	r.SetFontSize(9)
+	for i, b := range fs.Bars {
+		x := canvasBox.Left + xrange.Translate(float64(i))
+		y := canvasBox.Bottom - yrange.Translate(b.Value)
+
+		color := fs.UpColor
+		top, height := y, zero-y
+		if b.Value < 0 {
+			color = fs.DownColor
+			top, height = zero, y-zero
+		}
+		if height == 0 {
+			height = 1
+		}
+
+		fillRect(r, x-barWidth/2, top, barWidth, height, color)
+
+		texts, colors := []string{amountValueFormatter(b.Value)}, []drawing.Color{fs.TextColor}
+		if !math.IsNaN(b.Growth) {
+			growthColor := fs.UpColor
+			if b.Growth < 0 {
+				growthColor = fs.DownColor
+			}
+			texts, colors = append(texts, fmt.Sprintf("%+.1f%%", b.Growth)), append(colors, growthColor)
+		}
+
+		// labels are stacked outwards from the end of the bar: above positive bars and below negative ones
+		offset := 0
+		for j, text := range texts {
+			r.SetFontColor(colors[j])
+			box := r.MeasureText(text)
+			ly := top - 4 - offset
+			if b.Value < 0 {
+				ly = top + height + 4 + box.Height() + offset
+			}
+			r.Text(text, x-box.Width()/2, ly)
+			offset += box.Height() + 4
+		}
+	}
+
+	if yrange.GetMin() < 0 {
+		r.SetStrokeColor(fs.TextColor)
+		r.SetStrokeWidth(1)
+		r.MoveTo(canvasBox.Left, zero)
+		r.LineTo(canvasBox.Right, zero)
+		r.Stroke()
+	}
+}
@@ -0,0 +1,271 @@
+package yfapi
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"quote-telegram-bot/pkg/ta"
+)
+
+const (
+	// technicalsPeriod covers 52 weeks and enough bars for the slow trend average
+	technicalsPeriod = "2y"
+
+	trendFastPeriod = 50
+	trendSlowPeriod = 200
+	// pivotBars is a number of bars on each side a swing high or low is the extreme of
+	pivotBars = 5
+	// pivotLookback is a number of recent bars support and resistance levels are looked for in
+	pivotLookback = 126
+	// levels closer than levelMergeRange in percent are reported as one
+	levelMergeRange = 1.5
+	maxLevels       = 2
+	// macdCrossBars is how recent a MACD crossover is reported
+	macdCrossBars = 5
+)
+
+// TechnicalSummary is a state of technical indicators at the latest daily close.
+// Indicators which can not be calculated due to short history are NaN.
+type TechnicalSummary struct {
+	Symbol string
+	Date   time.Time
+	Close  float64
+
+	SMAFast float64
+	SMASlow float64
+	RSI     float64
+	MACD    float64
+	Signal  float64
+	// MACDCross is a number of bars since MACD crossed its signal line, -1 if it did not recently
+	MACDCross int
+
+	YearHigh float64
+	YearLow  float64
+
+	Support    []float64
+	Resistance []float64
+}
+
+// GetTechnicalSummary fetches daily bars of a symbol and summarizes its indicators.
+func (c *YFClient) GetTechnicalSummary(symbol string) (*TechnicalSummary, error) {
+	chart, err := c.GetDailyChart(symbol, technicalsPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	return chart.TechnicalSummary()
+}
+
+// TechnicalSummary calculates indicators the same way price chart overlays do, over daily bars of the chart.
+func (c *Chart) TechnicalSummary() (*TechnicalSummary, error) {
+	if len(c.Indicators.Quote) == 0 {
+		return nil, &EmptyDataError{Symbol: c.Meta.Symbol, Period: technicalsPeriod}
+	}
+
+	complete := c.completeBars()
+	q := complete.visibleQuote()
+	n := len(q.Close)
+	if n == 0 {
+		return nil, &EmptyDataError{Symbol: c.Meta.Symbol, Period: technicalsPeriod}
+	}
+	if n < macdSlow+macdSignal {
+		return nil, &InsufficientDataError{Symbol: c.Meta.Symbol, Days: n, Required: macdSlow + macdSignal}
+	}
+
+	last := n - 1
+	s := &TechnicalSummary{
+		Symbol:    c.Meta.Symbol,
+		Date:      time.Unix(int64(complete.Timestamps[complete.Lookback+last]), 0).In(complete.location()),
+		Close:     q.Close[last],
+		SMAFast:   ta.SMA(q.Close, trendFastPeriod)[last],
+		SMASlow:   ta.SMA(q.Close, trendSlowPeriod)[last],
+		RSI:       ta.RSI(q.Close, rsiPeriod)[last],
+		MACDCross: -1,
+	}
+
+	macd, signal, histogram := ta.MACD(q.Close, macdFast, macdSlow, macdSignal)
+	s.MACD, s.Signal = macd[last], signal[last]
+	for i := last; i > last-macdCrossBars && i > 0; i-- {
+		if !math.IsNaN(histogram[i-1]) && (histogram[i] > 0) != (histogram[i-1] > 0) {
+			s.MACDCross = last - i
+			break
+		}
+	}
+
+	// 52 weeks are taken by date, as exchanges differ in number of trading days
+	yearAgo := s.Date.AddDate(-1, 0, 0)
+	s.YearHigh, s.YearLow = math.Inf(-1), math.Inf(1)
+	for i := last; i >= 0; i-- {
+		if time.Unix(int64(complete.Timestamps[complete.Lookback+i]), 0).Before(yearAgo) {
+			break
+		}
+		s.YearHigh, s.YearLow = math.Max(s.YearHigh, q.High[i]), math.Min(s.YearLow, q.Low[i])
+	}
+
+	s.Support, s.Resistance = supportResistance(q, pivotLookback)
+
+	return s, nil
+}
+
+// supportResistance returns swing lows below the latest close and swing highs above it over recent bars,
+// nearest first. A swing high or low is the extreme of pivotBars bars on each side.
+func supportResistance(q ChartQuote, lookback int) (support, resistance []float64) {
+	n := len(q.Close)
+	price := q.Close[n-1]
+	start := n - lookback
+	if start < pivotBars {
+		start = pivotBars
+	}
+
+	var highs, lows []float64
+	for i := start; i < n-pivotBars; i++ {
+		isHigh, isLow := true, true
+		for j := i - pivotBars; j <= i+pivotBars; j++ {
+			if q.High[j] > q.High[i] {
+				isHigh = false
+			}
+			if q.Low[j] < q.Low[i] {
+				isLow = false
+			}
+		}
+		if isHigh && q.High[i] > price {
+			highs = append(highs, q.High[i])
+		}
+		if isLow && q.Low[i] < price {
+			lows = append(lows, q.Low[i])
+		}
+	}
+
+	sort.Float64s(highs)
+	sort.Sort(sort.Reverse(sort.Float64Slice(lows)))
+
+	return nearestLevels(lows), nearestLevels(highs)
+}
+
+// nearestLevels takes levels sorted by distance from the price, merging ones too close to each other.
+func nearestLevels(levels []float64) []float64 {
+	merged := make([]float64, 0, maxLevels)
+	for _, l := range levels {
+		if len(merged) == maxLevels {
+			break
+		}
+		if k := len(merged); k > 0 && math.Abs(l/merged[k-1]-1)*100 < levelMergeRange {
+			continue
+		}
+		merged = append(merged, l)
+	}
+
+	return merged
+}
+
+// TechnicalMessage describes the indicators in a few lines.
+func (s *TechnicalSummary) TechnicalMessage() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*%s technical summary* (%s, close %s)\n\n", s.Symbol, s.Date.Format("2006-01-02"), formatPrice(s.Close)))
+
+	sb.WriteString("*Trend:* " + s.trend() + "\n")
+
+	sb.WriteString(fmt.Sprintf("*RSI(%d):* ", rsiPeriod))
+	switch {
+	case math.IsNaN(s.RSI):
+		sb.WriteString("N/A\n")
+	case s.RSI >= rsiOverbought:
+		sb.WriteString(fmt.Sprintf("%.1f, overbought\n", s.RSI))
+	case s.RSI <= rsiOversold:
+		sb.WriteString(fmt.Sprintf("%.1f, oversold\n", s.RSI))
+	default:
+		sb.WriteString(fmt.Sprintf("%.1f, neutral\n", s.RSI))
+	}
+
+	sb.WriteString(fmt.Sprintf("*MACD(%d, %d, %d):* ", macdFast, macdSlow, macdSignal))
+	direction := "bullish, above signal"
+	if s.MACD < s.Signal {
+		direction = "bearish, below signal"
+	}
+	sb.WriteString(direction)
+	switch s.MACDCross {
+	case -1:
+	case 0:
+		sb.WriteString(", crossed today")
+	default:
+		sb.WriteString(fmt.Sprintf(", crossed %d days ago", s.MACDCross))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(fmt.Sprintf("*52W:* %+.1f%% from high %s, %+.1f%% from low %s\n",
+		(s.Close/s.YearHigh-1)*100, formatPrice(s.YearHigh), (s.Close/s.YearLow-1)*100, formatPrice(s.YearLow)))
+
+	sb.WriteString("*Support:* " + formatLevels(s.Support) + "\n")
+	sb.WriteString("*Resistance:* " + formatLevels(s.Resistance))
+
+	return sb.String()
+}
+
+// TechnicalMessageInlineKeyboard has a button plotting a year of prices with RSI and MACD panels.
+func (s *TechnicalSummary) TechnicalMessageInlineKeyboard() *tgbot.InlineKeyboardMarkup {
+	p := ChartParams{
+		Symbol:      s.Symbol,
+		Interval:    "1y",
+		Measurement: "price",
+		Action:      ActionChart,
+		Type:        "hasNoEarnings",
+		Style:       defaultChartStyle,
+		Options:     rsiOption + macdOption,
+	}
+
+	return NewCallbackKeyboard([][]tgbot.InlineKeyboardButton{{
+		tgbot.NewInlineKeyboardButtonData("Chart", p.CallbackData()),
+	}})
+}
+
+// trend compares the close with the fast and slow averages and the averages with each other.
+func (s *TechnicalSummary) trend() string {
+	if math.IsNaN(s.SMAFast) {
+		return "N/A, history is too short"
+	}
+
+	position := func(period int, sma float64) string {
+		side := "above"
+		if s.Close < sma {
+			side = "below"
+		}
+		return fmt.Sprintf("%s SMA(%d) %s (%+.1f%%)", side, period, formatPrice(sma), (s.Close/sma-1)*100)
+	}
+
+	if math.IsNaN(s.SMASlow) {
+		return "price " + position(trendFastPeriod, s.SMAFast)
+	}
+
+	trend := "sideways"
+	switch {
+	case s.Close > s.SMAFast && s.SMAFast > s.SMASlow:
+		trend = "uptrend"
+	case s.Close < s.SMAFast && s.SMAFast < s.SMASlow:
+		trend = "downtrend"
+	}
+	// the fast average above the slow one is known as a golden cross, below it as a death cross
+	cross := "golden cross"
+	if s.SMAFast < s.SMASlow {
+		cross = "death cross"
+	}
+
+	return fmt.Sprintf("%s (%s), price %s and %s", trend, cross,
+		position(trendFastPeriod, s.SMAFast), position(trendSlowPeriod, s.SMASlow))
+}
+
+func formatLevels(levels []float64) string {
+	if len(levels) == 0 {
+		return "N/A"
+	}
+
+	formatted := make([]string, 0, len(levels))
+	for _, l := range levels {
+		formatted = append(formatted, formatPrice(l))
+	}
+
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,237 @@
+package yfapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	tgbot "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// ChartTheme is a set of colors charts are drawn with. It is a go-chart ColorPalette,
+// so backgrounds, axes and titles pick the theme up, and adds colors of series go-chart knows nothing about.
+type ChartTheme struct {
+	Background drawing.Color
+	Text       drawing.Color
+	Axis       drawing.Color
+	// Muted is used for reference lines: indicator thresholds, Bollinger bands, previous close
+	Muted drawing.Color
+	// Primary is the price line, Secondary and Tertiary are indicators drawn over it
+	Primary   drawing.Color
+	Secondary drawing.Color
+	Tertiary  drawing.Color
+	Fill      drawing.Color
+	Up        drawing.Color
+	Down      drawing.Color
+	// Series colors are cycled for compared symbols, bars and donut slices
+	Series []drawing.Color
+}
+
+// ChartOutput is how charts are rendered for a particular user. It comes from user settings, not from callback data.
+type ChartOutput struct {
+	Theme string
+	// Scale multiplies chart dimensions and fonts for high-density screens
+	Scale  float64
+	Format string
+}
+
+const (
+	pngFormat = "png"
+	svgFormat = "svg"
+
+	defaultChartTheme = "light"
+	maxChartScale     = 3.0
+)
+
+var chartThemes = map[string]ChartTheme{
+	"light": {
+		Background: chart.ColorWhite,
+		Text:       chart.ColorBlack,
+		Axis:       chart.ColorBlack,
+		Muted:      chart.ColorAlternateGray,
+		Primary:    chart.ColorBlue,
+		Secondary:  chart.ColorOrange,
+		Tertiary:   chart.ColorRed,
+		Fill:       chart.ColorLightGray,
+		Up:         drawing.Color{R: 38, G: 166, B: 91, A: 255},
+		Down:       drawing.Color{R: 217, G: 48, B: 37, A: 255},
+		Series:     chart.DefaultColors,
+	},
+	"dark": {
+		Background: drawing.Color{R: 24, G: 26, B: 31, A: 255},
+		Text:       drawing.Color{R: 220, G: 221, B: 222, A: 255},
+		Axis:       drawing.Color{R: 140, G: 143, B: 148, A: 255},
+		Muted:      drawing.Color{R: 110, G: 118, B: 129, A: 255},
+		Primary:    drawing.Color{R: 66, G: 165, B: 245, A: 255},
+		Secondary:  drawing.Color{R: 255, G: 167, B: 38, A: 255},
+		Tertiary:   drawing.Color{R: 236, G: 64, B: 122, A: 255},
+		Fill:       drawing.Color{R: 33, G: 48, B: 66, A: 255},
+		Up:         drawing.Color{R: 38, G: 166, B: 154, A: 255},
+		Down:       drawing.Color{R: 239, G: 83, B: 80, A: 255},
+		Series: []drawing.Color{
+			{R: 66, G: 165, B: 245, A: 255},
+			{R: 102, G: 187, B: 106, A: 255},
+			{R: 236, G: 64, B: 122, A: 255},
+			{R: 38, G: 198, B: 218, A: 255},
+			{R: 255, G: 167, B: 38, A: 255},
+		},
+	},
+	"contrast": {
+		Background: drawing.ColorWhite,
+		Text:       drawing.ColorBlack,
+		Axis:       drawing.ColorBlack,
+		Muted:      drawing.Color{R: 64, G: 64, B: 64, A: 255},
+		Primary:    drawing.Color{R: 0, G: 0, B: 190, A: 255},
+		Secondary:  drawing.Color{R: 230, G: 120, B: 0, A: 255},
+		Tertiary:   drawing.Color{R: 170, G: 0, B: 170, A: 255},
+		Fill:       drawing.Color{R: 210, G: 215, B: 255, A: 255},
+		Up:         drawing.Color{R: 0, G: 130, B: 0, A: 255},
+		Down:       drawing.Color{R: 210, G: 0, B: 0, A: 255},
+		Series: []drawing.Color{
+			{R: 0, G: 0, B: 190, A: 255},
+			{R: 230, G: 120, B: 0, A: 255},
+			{R: 0, G: 130, B: 0, A: 255},
+			{R: 170, G: 0, B: 170, A: 255},
+			{R: 0, G: 0, B: 0, A: 255},
+		},
+	},
+}
+
+func (t ChartTheme) BackgroundColor() drawing.Color {
+	return t.Background
+}
+
+func (t ChartTheme) BackgroundStrokeColor() drawing.Color {
+	return t.Background
+}
+
+func (t ChartTheme) CanvasColor() drawing.Color {
+	return t.Background
+}
+
+func (t ChartTheme) CanvasStrokeColor() drawing.Color {
+	return t.Background
+}
+
+func (t ChartTheme) AxisStrokeColor() drawing.Color {
+	return t.Axis
+}
+
+func (t ChartTheme) TextColor() drawing.Color {
+	return t.Text
+}
+
+func (t ChartTheme) GetSeriesColor(index int) drawing.Color {
+	return t.Series[index%len(t.Series)]
+}
+
+// legendStyle keeps legends readable on dark backgrounds, go-chart always draws them white.
+func (t ChartTheme) legendStyle() chart.Style {
+	return chart.Style{
+		FillColor:   t.Background,
+		FontColor:   t.Text,
+		StrokeColor: t.Axis,
+	}
+}
+
+// ChartThemes returns names of available chart themes in alphabetical order.
+func ChartThemes() []string {
+	names := make([]string, 0, len(chartThemes))
+	for name := range chartThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func HasChartTheme(name string) bool {
+	_, ok := chartThemes[name]
+	return ok
+}
+
+// ParseChartScale parses a scale factor between 1 and maxChartScale.
+func ParseChartScale(text string) (float64, bool) {
+	scale, err := strconv.ParseFloat(text, 64)
+	if err != nil || !isChartScale(scale) {
+		return 0, false
+	}
+
+	return scale, true
+}
+
+// isChartScale reports whether the scale is supported, comparisons are false for NaN, so it is rejected too.
+func isChartScale(scale float64) bool {
+	return scale >= 1 && scale <= maxChartScale
+}
+
+func (o ChartOutput) theme() ChartTheme {
+	if t, ok := chartThemes[o.Theme]; ok {
+		return t
+	}
+
+	return chartThemes[defaultChartTheme]
+}
+
+// size scales chart dimensions in pixels, e.g. widths, heights and paddings.
+func (o ChartOutput) size(px int) int {
+	return int(float64(px) * o.scale())
+}
+
+// stroke scales line widths along with dimensions, otherwise lines look thinner on bigger charts.
+func (o ChartOutput) stroke(width float64) float64 {
+	return width * o.scale()
+}
+
+// dpi scales fonts, go-chart measures font sizes in points.
+func (o ChartOutput) dpi() float64 {
+	return chart.DefaultDPI * o.scale()
+}
+
+func (o ChartOutput) scale() float64 {
+	if !isChartScale(o.Scale) {
+		return 1
+	}
+
+	return o.Scale
+}
+
+func (o ChartOutput) isSVG() bool {
+	return o.Format == svgFormat
+}
+
+func (o ChartOutput) renderer() chart.RendererProvider {
+	if o.isSVG() {
+		return chart.SVG
+	}
+
+	return chart.PNG
+}
+
+// render draws any go-chart chart in the output format.
+func (o ChartOutput) render(graph interface {
+	Render(rp chart.RendererProvider, w io.Writer) error
+}) ([]byte, error) {
+	buffer := bytes.NewBuffer([]byte{})
+	if err := graph.Render(o.renderer(), buffer); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (o ChartOutput) fileBytes(b []byte) tgbot.FileBytes {
+	format := pngFormat
+	if o.isSVG() {
+		format = svgFormat
+	}
+
+	return tgbot.FileBytes{
+		Name:  fmt.Sprintf("charts.%s", format),
+		Bytes: b,
+	}
+}
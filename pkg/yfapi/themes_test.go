@@ -0,0 +1,56 @@
+package yfapi
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseChartScale(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  float64
+		valid bool
+	}{
+		{"1", 1, true},
+		{"1.5", 1.5, true},
+		{"3", 3, true},
+		{"0.5", 0, false},
+		{"4", 0, false},
+		{"nan", 0, false},
+		{"NaN", 0, false},
+		{"inf", 0, false},
+		{"-inf", 0, false},
+		{"big", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseChartScale(tt.text)
+		if ok != tt.valid || got != tt.want {
+			t.Errorf("ParseChartScale(%q) = %v, %v, want %v, %v", tt.text, got, ok, tt.want, tt.valid)
+		}
+	}
+}
+
+func TestChartOutputScale(t *testing.T) {
+	tests := []struct {
+		name  string
+		scale float64
+		want  float64
+	}{
+		{"unset", 0, 1},
+		{"valid", 2, 2},
+		{"too big", 10, 1},
+		{"NaN", math.NaN(), 1},
+		{"infinity", math.Inf(1), 1},
+	}
+
+	for _, tt := range tests {
+		o := ChartOutput{Scale: tt.scale}
+		if got := o.scale(); got != tt.want {
+			t.Errorf("%s: scale() = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := o.size(100); got != int(100*tt.want) {
+			t.Errorf("%s: size(100) = %d, want %d", tt.name, got, int(100*tt.want))
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package yfapi
+
+import (
+	"math"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+)
+
+// compressedBarStep is the distance between neighbouring bars on a compressed axis. Any value works,
+// as labels are looked up by bar index rather than derived from the plotted value.
+const compressedBarStep = time.Minute
+
+const intradayLayout = "Jan 2 15:04"
+
+// timeAxis maps bar timestamps to plotted X values and formats axis labels in exchange time.
+// On a compressed axis bars are placed evenly one after another, so closed market hours
+// do not stretch intraday charts with flat lines.
+type timeAxis struct {
+	// X are the values to plot series against
+	X          []time.Time
+	dates      []time.Time
+	location   *time.Location
+	layout     string
+	compressed bool
+}
+
+func newTimeAxis(timestamps []int, location *time.Location, intraday bool) *timeAxis {
+	axis := &timeAxis{
+		X:          make([]time.Time, 0, len(timestamps)),
+		dates:      make([]time.Time, 0, len(timestamps)),
+		location:   location,
+		layout:     chart.DefaultDateFormat,
+		compressed: intraday,
+	}
+
+	if intraday {
+		axis.layout = intradayLayout
+	}
+
+	for i, ts := range timestamps {
+		date := time.Unix(int64(ts), 0).In(location)
+		axis.dates = append(axis.dates, date)
+		if intraday {
+			axis.X = append(axis.X, time.Unix(int64(timestamps[0]), 0).Add(time.Duration(i)*compressedBarStep))
+			continue
+		}
+		axis.X = append(axis.X, date)
+	}
+
+	return axis
+}
+
+// newZoneAxis formats labels of an uncompressed axis in the time zone, e.g. of series of several symbols
+// traded at different hours, which can not share a compressed axis.
+func newZoneAxis(location *time.Location, intraday bool) *timeAxis {
+	axis := &timeAxis{location: location, layout: chart.DefaultDateFormat}
+	if intraday {
+		axis.layout = intradayLayout
+	}
+
+	return axis
+}
+
+// Format is a go-chart ValueFormatter for X axis ticks.
+func (a *timeAxis) Format(v interface{}) string {
+	value, ok := v.(float64)
+	if !ok {
+		return ""
+	}
+
+	if !a.compressed {
+		return time.Unix(0, int64(value)).In(a.location).Format(a.layout)
+	}
+	if len(a.dates) == 0 {
+		return ""
+	}
+
+	i := int(math.Round((value - chart.TimeToFloat64(a.X[0])) / float64(compressedBarStep)))
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(a.dates) {
+		i = len(a.dates) - 1
+	}
+
+	return a.dates[i].Format(a.layout)
+}
@@ -3,7 +3,11 @@ package yfapi
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	neturl "net/url"
 	"quote-telegram-bot/pkg/helpers"
+	"reflect"
+	"strings"
 	"time"
 
 	http "github.com/hashicorp/go-retryablehttp"
@@ -17,10 +21,56 @@ var (
 		"1mo": "1d",
 		"3mo": "1d",
 		"6mo": "5d",
+		"ytd": "1d",
 		"1y":  "5d",
+		"2y":  "1wk",
+		"5y":  "1wk",
+		"10y": "1mo",
+		"max": "1mo",
 	}
 
-	defaultPeriod = "1d"
+	// rollingRanges end at the current time and are offered for crypto instead of the shortest ranges. Crypto trades
+	// around the clock, so the session of the current day is only as long as time passed since midnight UTC.
+	rollingRanges = map[string]rollingRange{
+		"24h": {Span: 24 * time.Hour, Interval: "15m"},
+		"7d":  {Span: 7 * 24 * time.Hour, Interval: "1h"},
+	}
+	// cryptoRanges replace ranges of valid ones with rolling ranges
+	cryptoRanges = map[string]string{
+		"1d": "24h",
+		"5d": "7d",
+	}
+
+	granularities = map[string]time.Duration{
+		"15m": 15 * time.Minute,
+		"1h":  time.Hour,
+		"1d":  24 * time.Hour,
+		"5d":  5 * 24 * time.Hour,
+		"1wk": 7 * 24 * time.Hour,
+		"1mo": 30 * 24 * time.Hour,
+	}
+
+	defaultPeriod     = "1d"
+	defaultChartStyle = "line"
+	dateRangeLayout   = "20060102"
+
+	volumeOption    = "v"
+	smaOption       = "s"
+	emaOption       = "e"
+	bollingerOption = "b"
+	rsiOption       = "r"
+	macdOption      = "m"
+
+	smaPeriod           = 20
+	emaPeriod           = 50
+	bollingerPeriod     = 20
+	bollingerDeviations = 2.0
+	rsiPeriod           = 14
+	rsiOversold         = 30.0
+	rsiOverbought       = 70.0
+	macdFast            = 12
+	macdSlow            = 26
+	macdSignal          = 9
 )
 
 const (
@@ -31,11 +81,18 @@ const (
 	fundProfileModule          = "fundProfile"
 	priceModule                = "price"
 	financialDataModule        = "financialData"
+	topHoldingsModule          = "topHoldings"
+	summaryDetailModule        = "summaryDetail"
+
+	batchApiVersion = "v7"
 
 	chartsApiVersion = "v8"
 	chartMeta        = "meta"
 	chartTimestamps  = "timestamp"
 	chartIndicators  = "indicators"
+
+	// keeps callback data of comparison charts within Telegram limit of 64 bytes
+	maxComparedSymbols = 4
 )
 
 type YFClient struct {
@@ -54,15 +111,17 @@ func NewYFClient() *YFClient {
 
 func (c *YFClient) getQuoteResponse(symbol string) (QuoteData, error) {
 	url := fmt.Sprintf(
-		"https://query1.finance.yahoo.com/%s/finance/quoteSummary/%s?modules=%s,%s,%s,%s,%s,%s",
+		"https://query1.finance.yahoo.com/%s/finance/quoteSummary/%s?modules=%s,%s,%s,%s,%s,%s,%s,%s",
 		quotesApiVersion,
-		symbol,
+		neturl.PathEscape(symbol),
 		assetProfileModule,
 		defaultKeyStatisticsModule,
 		earningsModule,
 		fundProfileModule,
 		priceModule,
 		financialDataModule,
+		topHoldingsModule,
+		summaryDetailModule,
 	)
 
 	resp, err := c.Get(url)
@@ -123,24 +182,200 @@ func (c *YFClient) GetQuote(symbol string) (*Quote, error) {
 			if err = mapstructure.Decode(v, &quote.Price); err != nil {
 				return nil, err
 			}
+		case topHoldingsModule:
+			if err = mapstructure.Decode(v, &quote.Holdings); err != nil {
+				return nil, err
+			}
+		case summaryDetailModule:
+			if err = mapstructure.Decode(v, &quote.Summary); err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return &quote, nil
 }
 
-func (c *YFClient) getPriceChartResponse(symbol string, period string) (ChartData, error) {
+// rawValueHook unwraps {raw, fmt} objects into plain numbers for numeric fields. v7 quote API formats
+// timestamps this way too, while quoteSummary returns them as plain numbers.
+func rawValueHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.Map || to.Kind() != reflect.Int64 {
+		return data, nil
+	}
+
+	if m, ok := data.(map[string]interface{}); ok {
+		if raw, ok := m["raw"]; ok {
+			return raw, nil
+		}
+	}
+
+	return data, nil
+}
+
+func (c *YFClient) getBatchQuoteResponse(symbols []string) (BatchData, error) {
+	escaped := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		escaped = append(escaped, neturl.QueryEscape(helpers.Sanitize(s)))
+	}
+
+	url := fmt.Sprintf("https://query1.finance.yahoo.com/%s/finance/quote?formatted=true&symbols=%s",
+		batchApiVersion,
+		strings.Join(escaped, ","),
+	)
+
+	resp, err := c.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	parsedResp := &BatchResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(parsedResp); err != nil {
+		return nil, err
+	}
+
+	if parsedResp.Data.Error.Code != "" {
+		return nil, &parsedResp.Data.Error
+	}
+
+	return parsedResp.Data.Data, nil
+}
+
+// GetQuotes fetches price data of several symbols in one request. Only Quote.Price is filled,
+// and results of types not supported by search are skipped.
+func (c *YFClient) GetQuotes(symbols []string) ([]*Quote, error) {
+	data, err := c.getBatchQuoteResponse(symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make([]*Quote, 0, len(data))
+	for _, v := range data {
+		quote := Quote{}
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook: rawValueHook,
+			Result:     &quote.Price,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = decoder.Decode(v); err != nil {
+			return nil, err
+		}
+		if _, ok := searchTypes[quote.Price.Type]; !ok {
+			continue
+		}
+		quotes = append(quotes, &quote)
+	}
+
+	return quotes, nil
+}
+
+// CrossRates fetches USD rates of all currencies in one batch and derives every other pair from them.
+func (c *YFClient) CrossRates(currencies []string) (*CrossRates, error) {
+	if len(currencies) < 2 || len(currencies) > maxCrossRateCurrencies {
+		return nil, &QueryError{
+			Code:        "Bad Request",
+			Description: fmt.Sprintf("Provide from 2 to %d currencies", maxCrossRateCurrencies),
+		}
+	}
+
+	symbols := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		if currency != baseCurrency {
+			symbols = append(symbols, currencySymbol(baseCurrency, currency))
+		}
+	}
+
+	usdRates := map[string]float64{baseCurrency: 1}
+	var ts time.Time
+	if len(symbols) > 0 {
+		quotes, err := c.GetQuotes(symbols)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, q := range quotes {
+			if q.Price.Type != "CURRENCY" || q.Price.MarketPrice.Raw == 0 {
+				continue
+			}
+			_, currency := q.CurrencyPair()
+			usdRates[currency] = q.Price.MarketPrice.Raw
+			if t := time.Unix(q.Price.MarketTime, 0); ts.IsZero() || t.Before(ts) {
+				ts = t
+			}
+		}
+	}
+
+	for _, currency := range currencies {
+		if _, ok := usdRates[currency]; !ok {
+			return nil, &QueryError{
+				Code:        "Not Found",
+				Description: fmt.Sprintf("No exchange rate for %s/%s", baseCurrency, currency),
+			}
+		}
+	}
+
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	return newCrossRates(currencies, usdRates, ts), nil
+}
+
+type rollingRange struct {
+	Span     time.Duration
+	Interval string
+}
+
+// PriceRanges are periods price charts are drawn over, shortest first.
+var PriceRanges = []string{"1d", "5d", "1mo", "3mo", "6mo", "ytd", "1y", "2y", "5y", "10y", "max"}
+
+// HasPriceRange reports whether a price chart can be drawn over the period.
+func HasPriceRange(period string) bool {
+	_, ok := priceIntervals[period]
+	return ok
+}
+
+// periodInterval returns supported chart period and its granularity, falling back to the default period.
+func periodInterval(period string) (string, string) {
+	if from, to, ok := ParseDateRange(period); ok {
+		return period, dateRangeInterval(from, to)
+	}
+	if r, ok := rollingRanges[period]; ok {
+		return period, r.Interval
+	}
+
 	interval, ok := priceIntervals[period]
 	if !ok {
 		period = defaultPeriod
 		interval = priceIntervals[period]
 	}
 
-	url := fmt.Sprintf("https://query1.finance.yahoo.com/%s/finance/chart/%s?period1=0&period2=9999999999&interval=%s&range=%s",
+	return period, interval
+}
+
+// dateRangeInterval picks granularity giving from a few dozen to a few hundred bars for the range.
+// Yahoo Finance keeps hourly data for the last 730 days only.
+func dateRangeInterval(from, to time.Time) string {
+	span := to.Sub(from)
+	switch {
+	case span <= 7*24*time.Hour && time.Since(from) < 730*24*time.Hour:
+		return "1h"
+	case span <= 366*24*time.Hour:
+		return "1d"
+	case span <= 5*366*24*time.Hour:
+		return "1wk"
+	default:
+		return "1mo"
+	}
+}
+
+func (c *YFClient) getChartResponse(symbol string, query string) (ChartData, error) {
+	url := fmt.Sprintf("https://query1.finance.yahoo.com/%s/finance/chart/%s?%s",
 		chartsApiVersion,
-		symbol,
-		interval,
-		period,
+		neturl.PathEscape(symbol),
+		query,
 	)
 
 	resp, err := c.Get(url)
@@ -161,33 +396,261 @@ func (c *YFClient) getPriceChartResponse(symbol string, period string) (ChartDat
 	return parsedResp.Data.Data, nil
 }
 
-func (c *YFClient) GetPriceChart(symbol string, period string) (*Chart, error) {
-	data, err := c.getPriceChartResponse(symbol, period)
-	if err != nil {
-		return nil, err
+func (c *YFClient) getPriceChartResponse(symbol string, period string) (ChartData, error) {
+	period, interval := periodInterval(period)
+	if from, to, ok := ParseDateRange(period); ok {
+		return c.getChartResponse(symbol, dateRangeQuery(from, to, interval))
+	}
+	if r, ok := rollingRanges[period]; ok {
+		now := time.Now()
+		return c.getChartResponse(symbol, fmt.Sprintf("period1=%d&period2=%d&interval=%s", now.Add(-r.Span).Unix(), now.Unix(), interval))
+	}
+
+	return c.getChartResponse(symbol, fmt.Sprintf("period1=0&period2=9999999999&interval=%s&range=%s", interval, period))
+}
+
+func decodeChart(symbol string, period string, data ChartData) (*Chart, error) {
+	if len(data) == 0 {
+		return nil, &EmptyDataError{Symbol: symbol, Period: period}
 	}
 
 	chart := Chart{}
 	for k, v := range data[0] {
 		switch k {
 		case chartMeta:
-			if err = mapstructure.Decode(v, &chart.Meta); err != nil {
+			if err := mapstructure.Decode(v, &chart.Meta); err != nil {
 				return nil, err
 			}
 		case chartIndicators:
-			if err = mapstructure.Decode(v, &chart.Indicators); err != nil {
+			if err := mapstructure.Decode(withMissingValues(v), &chart.Indicators); err != nil {
 				return nil, err
 			}
 		case chartTimestamps:
-			if err = mapstructure.Decode(v, &chart.Timestamps); err != nil {
+			if err := mapstructure.Decode(v, &chart.Timestamps); err != nil {
 				return nil, err
 			}
 		}
 	}
 
+	if err := chart.validate(symbol, period); err != nil {
+		return nil, err
+	}
+
 	return &chart, nil
 }
 
+// withMissingValues replaces nulls Yahoo Finance puts into chart arrays for bars without trades.
+// Missing prices become NaN, so they are not mistaken for zero prices, and missing volume becomes zero.
+func withMissingValues(indicators interface{}) interface{} {
+	m, ok := indicators.(map[string]interface{})
+	if !ok {
+		return indicators
+	}
+
+	quotes, ok := m["quote"].([]interface{})
+	if !ok {
+		return indicators
+	}
+
+	for _, q := range quotes {
+		fields, ok := q.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for name, values := range fields {
+			list, ok := values.([]interface{})
+			if !ok {
+				continue
+			}
+			var missing interface{} = math.NaN()
+			if name == "volume" {
+				missing = 0.0
+			}
+			for i, v := range list {
+				if v == nil {
+					list[i] = missing
+				}
+			}
+		}
+	}
+
+	return indicators
+}
+
+func (c *YFClient) GetPriceChart(symbol string, period string) (*Chart, error) {
+	data, err := c.getPriceChartResponse(symbol, period)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeChart(symbol, period, data)
+}
+
+// GetDailyChart fetches daily bars of a symbol over the period, regardless of granularity the period is plotted with,
+// e.g. to calculate statistics over daily returns.
+func (c *YFClient) GetDailyChart(symbol string, period string) (*Chart, error) {
+	period, _ = periodInterval(period)
+	query := fmt.Sprintf("period1=0&period2=9999999999&interval=1d&range=%s", period)
+	if from, to, ok := ParseDateRange(period); ok {
+		query = dateRangeQuery(from, to, "1d")
+	}
+
+	data, err := c.getChartResponse(symbol, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeChart(symbol, period, data)
+}
+
+// GetComparison fetches price charts of several symbols over the same period.
+func (c *YFClient) GetComparison(symbols []string, period string) (*Comparison, error) {
+	if len(symbols) < 2 || len(symbols) > maxComparedSymbols {
+		return nil, &QueryError{
+			Code:        "Bad Request",
+			Description: fmt.Sprintf("Provide from 2 to %d symbols", maxComparedSymbols),
+		}
+	}
+
+	comparison := &Comparison{
+		Charts: make([]*Chart, 0, len(symbols)),
+	}
+	for _, symbol := range symbols {
+		chart, err := c.GetPriceChart(symbol, period)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Charts = append(comparison.Charts, chart)
+	}
+
+	return comparison, nil
+}
+
+// GetPriceChartWithLookback fetches price chart along with at least bars of history preceding the period,
+// so indicators calculated over the chart are valid from its first visible bar.
+// Lookback is best effort: if history can not be fetched, chart is returned without it.
+func (c *YFClient) GetPriceChartWithLookback(symbol string, period string, bars int) (*Chart, error) {
+	chart, err := c.GetPriceChart(symbol, period)
+	if err != nil {
+		return nil, err
+	}
+
+	_, interval := periodInterval(period)
+	c.addLookback(chart, symbol, period, interval, bars)
+
+	return chart, nil
+}
+
+// GetDailyChartWithLookback fetches daily bars of a symbol over the period along with at least bars of daily history
+// preceding it, e.g. for indicators a backtest trades on. Lookback is best effort the same way as for price charts.
+func (c *YFClient) GetDailyChartWithLookback(symbol string, period string, bars int) (*Chart, error) {
+	chart, err := c.GetDailyChart(symbol, period)
+	if err != nil {
+		return nil, err
+	}
+
+	c.addLookback(chart, symbol, period, "1d", bars)
+
+	return chart, nil
+}
+
+// addLookback prepends bars of history preceding the chart, the chart is left as is if history can not be fetched.
+func (c *YFClient) addLookback(chart *Chart, symbol, period, interval string, bars int) {
+	if bars == 0 || len(chart.Timestamps) == 0 {
+		return
+	}
+
+	first := chart.Timestamps[0]
+	data, err := c.getChartResponse(symbol, fmt.Sprintf("period1=%d&period2=%d&interval=%s",
+		first-int(lookbackSpan(interval, bars).Seconds()),
+		first,
+		interval,
+	))
+	if err != nil {
+		return
+	}
+
+	history, err := decodeChart(symbol, period, data)
+	if err != nil {
+		return
+	}
+
+	chart.prepend(history)
+}
+
+// lookbackSpan estimates calendar time covering bars of given granularity. Markets are closed at nights,
+// weekends and holidays, so intraday bars need much more calendar time than their total duration.
+func lookbackSpan(interval string, bars int) time.Duration {
+	granularity, ok := granularities[interval]
+	if !ok {
+		granularity = 24 * time.Hour
+	}
+
+	factor := 2
+	if granularity < 24*time.Hour {
+		factor = 6
+	}
+
+	return time.Duration(bars*factor) * granularity
+}
+
+func (c *YFClient) currencyRate(from, to string) (float64, time.Time, error) {
+	if from == to {
+		return 1, time.Now(), nil
+	}
+
+	quote, err := c.GetQuote(currencySymbol(from, to))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	if quote.Price.Type != "CURRENCY" || quote.Price.MarketPrice.Raw == 0 {
+		return 0, time.Time{}, &QueryError{
+			Code:        "Not Found",
+			Description: fmt.Sprintf("No exchange rate for %s/%s", from, to),
+		}
+	}
+
+	return quote.Price.MarketPrice.Raw, time.Unix(quote.Price.MarketTime, 0), nil
+}
+
+// Convert resolves exchange rate for a currency pair. When Yahoo Finance has no direct pair,
+// the rate is triangulated via USD quotes of both currencies.
+func (c *YFClient) Convert(amount float64, from, to string) (*Conversion, error) {
+	conversion := &Conversion{
+		Amount: amount,
+		From:   from,
+		To:     to,
+	}
+
+	rate, ts, err := c.currencyRate(from, to)
+	if err == nil {
+		conversion.Rate = rate
+		conversion.Time = ts
+		return conversion, nil
+	}
+
+	fromRate, fromTS, err := c.currencyRate(baseCurrency, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRate, toTS, err := c.currencyRate(baseCurrency, to)
+	if err != nil {
+		return nil, err
+	}
+
+	conversion.Rate = toRate / fromRate
+	conversion.Via = baseCurrency
+	// rate is as fresh as the oldest of its legs
+	conversion.Time = fromTS
+	if toTS.Before(fromTS) {
+		conversion.Time = toTS
+	}
+
+	return conversion, nil
+}
+
 func (c *YFClient) Search(text string) (*SearchResponse, error) {
 	url := fmt.Sprintf(
 		"https://query1.finance.yahoo.com/v1/finance/search?q=%s"+